@@ -1,12 +1,15 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Board reprezintă tabla de joc pentru Memory Scramble
@@ -15,22 +18,103 @@ import (
 //   - len(Cards) == Rows
 //   - Pentru tot i: len(Cards[i]) == Cols
 //   - version >= 0
+//   - 0 <= changeLogBase <= version și changes are câte o intrare pentru fiecare
+//     versiune din (changeLogBase, version], în ordine
 //   - Toate cărțile din Cards respectă Card.checkRep()
+//   - turnOrder nu are duplicate; 0 <= turn < len(turnOrder) dacă turnOrder nu e gol
 //
 // Thread Safety:
-//   - Cards și version sunt protejate de mu (RWMutex)
+//   - Cards, version, waiters, change log-ul, gameOver, winners, setările de reset,
+//     journal-ul, matcher, groupSize, turele, idleTimeout, clock și spectators sunt protejate de mu (RWMutex)
 //   - listeners este protejat de listenersMu
 //   - playerStates este protejat de playerStatesMu
 type Board struct {
-	Rows           int                     // Numărul de rânduri
-	Cols           int                     // Numărul de coloane
-	Cards          [][]Card                // Matricea de cărți
-	version        int                     // Versiunea tablei (incrementată la fiecare modificare)
-	mu             sync.RWMutex            // Protejează Cards și version
-	listeners      map[chan struct{}]bool  // Canale pentru watch requests
-	listenersMu    sync.Mutex              // Protejează listeners
-	playerStates   map[string]*PlayerState // Stările jucătorilor
-	playerStatesMu sync.Mutex              // Protejează playerStates
+	Rows           int                        // Numărul de rânduri
+	Cols           int                        // Numărul de coloane
+	Cards          [][]Card                   // Matricea de cărți
+	version        int                        // Versiunea tablei (incrementată la fiecare schimbare vizibilă)
+	mu             sync.RWMutex               // Protejează Cards, version și waiters
+	waiters        map[Position][]*cardWaiter // Cozi FIFO de flip-uri care așteaptă o carte (regula 1-D)
+	listeners      map[chan struct{}]bool     // Canale pentru watch requests
+	listenersMu    sync.Mutex                 // Protejează listeners
+	playerStates   map[string]*PlayerState    // Stările jucătorilor
+	playerStatesMu sync.Mutex                 // Protejează playerStates
+	changes        []changeEntry              // Change log-ul: celulele schimbate la fiecare versiune (cel mult maxChangeLog)
+	changeLogBase  int                        // change log-ul acoperă versiunile (changeLogBase, version]
+	pendingChanges []Position                 // Celule schimbate doar ca control de la ultima versiune
+	layout         [][]string                 // Valorile inițiale ale cărților, folosite la reset
+	gameOver       bool                       // true după ce toate cărțile au fost eliminate
+	winners        []string                   // Câștigătorii jocului terminat (nil dacă jocul continuă)
+	resetMode      ResetMode                  // Ce se întâmplă cu tabla după terminarea jocului
+	resetDelay     time.Duration              // Cât se așteaptă înainte de reset
+	resetTimer     *time.Timer                // Reset-ul programat (nil dacă nu există)
+	journal        *Journal                   // Journal-ul operațiilor (nil dacă nu se scrie)
+	pendingEntries []JournalEntry             // Operații încă nescrise în journal, până la commitChanges
+	matcher        Matcher                    // Regula de potrivire a cărților (nil = valori identice)
+	groupSize      int                        // Câte cărți formează un grup (0 = 2, perechi)
+	turnBased      bool                       // true dacă doar jucătorul activ poate întoarce cărți
+	turnOrder      []string                   // Jucătorii în ordinea intrării în joc (modul pe ture)
+	turn           int                        // Indexul jucătorului activ în turnOrder
+	turnMoved      bool                       // true dacă jucătorul activ s-a schimbat de la ultima versiune
+	turnStarted    time.Time                  // Momentul în care jucătorul activ a primit rândul
+	idleTimeout    time.Duration              // Inactivitatea după care cărțile unui jucător sunt eliberate (0 = fără limită)
+	clock          func() time.Time           // Ceasul tablei (nil = time.Now), înlocuibil în teste
+	spectators     map[string]int             // Spectatorii care privesc acum tabla, cu numărul lor de conexiuni (vezi Spectate)
+	closed         chan struct{}              // Închis de Close, când jocul este șters
+	closeOnce      sync.Once                  // Închide closed o singură dată
+}
+
+// Position identifică o celulă de pe tablă
+type Position struct {
+	Row int `json:"row"` // Rândul (0-indexed)
+	Col int `json:"col"` // Coloana (0-indexed)
+}
+
+// cardWaiter reprezintă un flip care așteaptă eliberarea unei cărți controlate de altcineva
+type cardWaiter struct {
+	ch    chan struct{} // Închis când cartea devine disponibilă pentru acest waiter
+	woken bool          // true dacă ch a fost deja închis
+}
+
+// NewBoard creează o tablă nouă din matricea de cărți dată
+//
+// Specification:
+//
+//	Parameters:
+//	  - cards: matricea de cărți, cu cel puțin un rând și o coloană
+//	Returns:
+//	  - *Board: tabla nouă, cu version 0 și fără jucători
+//	Preconditions:
+//	  - Toate rândurile din cards au aceeași lungime
+//	Postconditions:
+//	  - Board-ul returnat respectă representation invariants
+//	  - Cards din board este chiar cards (nu se face copie)
+//	  - Valorile inițiale ale cărților sunt reținute pentru Reset
+//	  - Reset-ul automat este dezactivat (ResetNone)
+func NewBoard(cards [][]Card) *Board {
+	board := &Board{
+		Rows:         len(cards),
+		Cards:        cards,
+		version:      0,
+		waiters:      make(map[Position][]*cardWaiter),
+		listeners:    make(map[chan struct{}]bool),
+		playerStates: make(map[string]*PlayerState),
+		layout:       make([][]string, len(cards)),
+		resetMode:    ResetNone,
+		closed:       make(chan struct{}),
+	}
+	if len(cards) > 0 {
+		board.Cols = len(cards[0])
+	}
+	for i, row := range cards {
+		board.layout[i] = make([]string, len(row))
+		for j, card := range row {
+			board.layout[i][j] = card.Value
+		}
+	}
+
+	board.checkRep()
+	return board
 }
 
 // LoadBoardFromFile încarcă tabla de joc dintr-un fișier
@@ -39,14 +123,14 @@ type Board struct {
 //
 //	Parameters:
 //	  - filename: calea către fișierul care conține configurația tablei
+//	  - opts: verificările suplimentare (ex: ParseOptions{GroupSize: 2} pentru perechi)
 //	Returns:
 //	  - *Board: pointer către tabla încărcată, sau nil dacă apare eroare
 //	  - error: nil dacă operația reușește, altfel eroarea întâlnită
+//	           (*ParseError dacă fișierul nu respectă formatul)
 //	Preconditions:
 //	  - filename trebuie să existe și să fie citibil
-//	  - Fișierul trebuie să aibă formatul:
-//	      Linia 1: "RxC" (dimensiuni)
-//	      Liniile următoare: R*C valori de cărți
+//	  - Fișierul trebuie să aibă formatul acceptat de ParseBoard
 //	Postconditions:
 //	  - Dacă reușește: returnează Board valid care respectă invarianții
 //	  - Dacă eșuează: returnează nil și error non-nil
@@ -54,52 +138,14 @@ type Board struct {
 //	Effects:
 //	  - Citește din fișierul specificat
 //	  - Alocă memorie pentru Board și Cards
-func LoadBoardFromFile(filename string) (*Board, error) {
+func LoadBoardFromFile(filename string, opts ParseOptions) (*Board, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-
-	// Citește prima linie cu dimensiunile (ex: "3x3")
-	if !scanner.Scan() {
-		return nil, fmt.Errorf("empty file")
-	}
-	dims := strings.Split(scanner.Text(), "x")
-	rows, _ := strconv.Atoi(dims[0])
-	cols, _ := strconv.Atoi(dims[1])
-
-	// Creează matricea de cărți
-	cards := make([][]Card, rows)
-	for i := 0; i < rows; i++ {
-		cards[i] = make([]Card, cols)
-		for j := 0; j < cols; j++ {
-			if !scanner.Scan() {
-				return nil, fmt.Errorf("not enough cards in file")
-			}
-			// Fiecare carte începe cu fața în jos și necontrolată
-			cards[i][j] = Card{
-				Value:      scanner.Text(),
-				FaceUp:     false,
-				Controller: "",
-			}
-		}
-	}
-
-	// Creează Board-ul
-	board := &Board{
-		Rows:         rows,
-		Cols:         cols,
-		Cards:        cards,
-		version:      0,
-		listeners:    make(map[chan struct{}]bool),
-		playerStates: make(map[string]*PlayerState),
-	}
-
-	board.checkRep()
-	return board, nil
+	return ParseBoard(file, opts)
 }
 
 // checkRep verifică representation invariants pentru Board
@@ -138,6 +184,18 @@ func (b *Board) checkRep() {
 	}
 }
 
+// InBounds verifică dacă (row, col) este o poziție de pe tablă
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă 0 <= row < Rows și 0 <= col < Cols
+//	Thread Safety:
+//	  - Rows și Cols nu se schimbă după creare, deci nu e nevoie de lock
+func (b *Board) InBounds(row, col int) bool {
+	return row >= 0 && row < b.Rows && col >= 0 && col < b.Cols
+}
+
 // GetPlayerState returnează sau creează starea pentru un jucător
 //
 // Specification:
@@ -168,6 +226,61 @@ func (b *Board) GetPlayerState(playerID string) *PlayerState {
 	return b.playerStates[playerID]
 }
 
+// Scores returnează statisticile tuturor jucătorilor care au făcut cel puțin o acțiune
+//
+// Specification:
+//
+//	Returns:
+//	  - []PlayerScore: sortat descrescător după Pairs, apoi crescător după
+//	    FailedFlips și PlayerID
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu.RLock, apoi playerStatesMu)
+func (b *Board) Scores() []PlayerScore {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	b.playerStatesMu.Lock()
+	defer b.playerStatesMu.Unlock()
+
+	scores := make([]PlayerScore, 0, len(b.playerStates))
+	for playerID, state := range b.playerStates {
+		scores = append(scores, PlayerScore{
+			PlayerID:    playerID,
+			Pairs:       state.Pairs,
+			FailedFlips: state.FailedFlips,
+			LastAction:  state.LastAction,
+		})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Pairs != scores[j].Pairs {
+			return scores[i].Pairs > scores[j].Pairs
+		}
+		if scores[i].FailedFlips != scores[j].FailedFlips {
+			return scores[i].FailedFlips < scores[j].FailedFlips
+		}
+		return scores[i].PlayerID < scores[j].PlayerID
+	})
+	return scores
+}
+
+// FormatScores formatează clasamentul, câte un jucător pe linie
+//
+// Specification:
+//
+//	Parameters:
+//	  - scores: rezultatul lui Board.Scores
+//	  - prefix: prefixul fiecărei linii (ex: "score " când se adaugă după tablă)
+//	Returns:
+//	  - string: pentru fiecare jucător, în ordine, linia
+//	      "{prefix}{playerID} {pairs} {failedFlips} {lastAction în RFC 3339}"
+func FormatScores(scores []PlayerScore, prefix string) string {
+	var result strings.Builder
+	for _, score := range scores {
+		result.WriteString(fmt.Sprintf("%s%s %d %d %s\n", prefix, score.PlayerID,
+			score.Pairs, score.FailedFlips, score.LastAction.UTC().Format(time.RFC3339)))
+	}
+	return result.String()
+}
+
 // FormatBoard formatează tabla pentru afișare către un jucător specific
 //
 // Specification:
@@ -182,6 +295,9 @@ func (b *Board) GetPlayerState(playerID string) *PlayerState {
 //	        "down" - carte cu fața în jos
 //	        "my X" - carte controlată de acest jucător cu valoarea X
 //	        "up X" - carte vizibilă cu valoarea X (altcineva sau nimeni)
+//	      În modul pe ture, linia "turn {player}" cu jucătorul care este la rând
+//	      Dacă jocul s-a terminat, o ultimă linie "over" urmată de câștigători
+//	      (vezi FormatGameOver)
 //	Preconditions:
 //	  - playerID poate fi orice string (inclusiv gol)
 //	Postconditions:
@@ -192,36 +308,202 @@ func (b *Board) GetPlayerState(playerID string) *PlayerState {
 //	Effects:
 //	  - Citește din Cards (read lock pe mu)
 func (b *Board) FormatBoard(playerID string) string {
-	b.mu.RLock() // Lock pentru citire (permite citiri simultane)
+	text, _ := b.FormatBoardWithVersion(playerID)
+	return text
+}
+
+// FormatBoardWithVersion formatează tabla ca FormatBoard și returnează versiunea ei
+//
+// Specification:
+//
+//	Parameters:
+//	  - playerID: identificatorul jucătorului pentru care se formatează
+//	Returns:
+//	  - string: aceeași reprezentare ca FormatBoard(playerID)
+//	  - int: versiunea tablei care corespunde exact textului returnat
+//	Thread Safety:
+//	  - Funcția este thread-safe (textul și versiunea vin din același Snapshot)
+func (b *Board) FormatBoardWithVersion(playerID string) (string, int) {
+	snapshot := b.Snapshot(playerID)
+	return snapshot.Text(), snapshot.Version
+}
+
+// FormatBoardAs formatează tabla văzută de viewer
+//
+// Specification:
+//
+//	Parameters:
+//	  - viewer: jucătorul sau spectatorul (vezi Viewer)
+//	Returns:
+//	  - string: pentru PlayerView(playerID), exact FormatBoard(playerID); un
+//	    spectator nu vede niciodată "my X", iar cu ShowControllers cărțile
+//	    controlate apar ca "up X by {controller}"
+//	Thread Safety:
+//	  - Funcția este thread-safe (vezi SnapshotAs)
+func (b *Board) FormatBoardAs(viewer Viewer) string {
+	return b.SnapshotAs(viewer, -1).Text()
+}
+
+// Version returnează versiunea curentă a tablei
+//
+// Specification:
+//
+//	Returns:
+//	  - int: numărul de schimbări vizibile de la încărcarea tablei
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu.RLock)
+func (b *Board) Version() int {
+	b.mu.RLock()
 	defer b.mu.RUnlock()
+	return b.version
+}
 
-	var result strings.Builder
+// WaitForChange așteaptă până când versiunea tablei devine mai mare decât since
+//
+// Specification:
+//
+//	Parameters:
+//	  - ctx: contextul request-ului (anulare sau timeout)
+//	  - since: ultima versiune văzută de client
+//	Returns:
+//	  - int: versiunea curentă, > since, dacă error == nil
+//	  - error: ctx.Err() dacă ctx se termină înainte de o schimbare vizibilă,
+//	    ErrGameDeleted dacă tabla este închisă (vezi Close)
+//	Postconditions:
+//	  - Returnează imediat dacă tabla s-a schimbat deja după since,
+//	    deci un client care reia cu ultima versiune nu pierde schimbări
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește listenersMu și mu.RLock)
+//	Effects:
+//	  - Adaugă un canal în listeners și îl șterge la final
+func (b *Board) WaitForChange(ctx context.Context, since int) (int, error) {
+	// Creează un canal pentru notificări
+	ch := make(chan struct{}, 1)
+
+	// Adaugă canalul înainte de a citi versiunea, ca să nu pierdem o notificare
+	b.listenersMu.Lock()
+	b.listeners[ch] = true
+	b.listenersMu.Unlock()
+
+	// La final, șterge canalul din listeners
+	defer func() {
+		b.listenersMu.Lock()
+		delete(b.listeners, ch)
+		b.listenersMu.Unlock()
+	}()
 
-	// Prima linie: dimensiunile
-	result.WriteString(fmt.Sprintf("%dx%d\n", b.Rows, b.Cols))
+	for {
+		if version := b.Version(); version > since {
+			return version, nil
+		}
+		select {
+		case <-ch:
+			// Versiunea s-a schimbat, verifică din nou
+		case <-b.closed:
+			return 0, ErrGameDeleted
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
 
-	// Pentru fiecare carte, scrie starea ei
+// ErrGameDeleted este returnată operațiilor care așteptau pe o tablă închisă
+var ErrGameDeleted = errors.New("game deleted")
+
+// Close închide tabla când jocul ei este șters
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Tabla a fost creată cu NewBoard
+//	Postconditions:
+//	  - Flip-urile care așteaptă o carte (regula 1-D) și watch-urile (WaitForChange)
+//	    se termină cu ErrGameDeleted, la fel ca cele care încep să aștepte după Close
+//	  - Reset-ul automat este oprit (vezi StopAutoReset)
+//	  - Apelurile repetate nu mai au efect
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) Close() {
+	b.StopAutoReset()
+	b.closeOnce.Do(func() { close(b.closed) })
+}
+
+// cardFace este partea unei cărți observabilă de jucători
+type cardFace struct {
+	Value      string // Valoarea cărții sau "" dacă e eliminată
+	FaceUp     bool   // true = vizibilă
+	Controller string // Jucătorul care controlează cartea (apare ca "my" pentru el)
+}
+
+// visible returnează partea din cardFace observabilă prin watch (fără control)
+func (f cardFace) visible() cardFace {
+	return cardFace{Value: f.Value, FaceUp: f.FaceUp}
+}
+
+// faces returnează partea observabilă a tuturor cărților
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu
+//	Postconditions:
+//	  - Returnează o copie; modificările ulterioare ale Cards nu o afectează
+func (b *Board) faces() [][]cardFace {
+	result := make([][]cardFace, b.Rows)
 	for i := 0; i < b.Rows; i++ {
+		result[i] = make([]cardFace, b.Cols)
 		for j := 0; j < b.Cols; j++ {
 			card := b.Cards[i][j]
+			result[i][j] = cardFace{Value: card.Value, FaceUp: card.FaceUp, Controller: card.Controller}
+		}
+	}
+	return result
+}
 
-			if card.Value == "" {
-				// Carte eliminată
-				result.WriteString("none\n")
-			} else if !card.FaceUp {
-				// Carte cu fața în jos
-				result.WriteString("down\n")
-			} else if card.Controller == playerID {
-				// Carte controlată de acest jucător
-				result.WriteString(fmt.Sprintf("my %s\n", card.Value))
-			} else {
-				// Carte vizibilă (controlată de altcineva sau necontrolată)
-				result.WriteString(fmt.Sprintf("up %s\n", card.Value))
+// commitChanges publică modificările făcute după snapshot-ul before
+//
+// Specification:
+//
+//	Parameters:
+//	  - before: rezultatul lui b.faces() de dinaintea modificării
+//	Returns:
+//	  - bool: true dacă vreo carte s-a întors, a fost eliminată sau și-a schimbat valoarea
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Dacă returnează true sau jucătorul activ s-a schimbat (modul pe ture): version
+//	    este incrementat, celulele schimbate de la versiunea anterioară sunt adăugate
+//	    în change log și listeners sunt notificați
+//	  - Schimbările doar de control (ex: regula 1-C) nu modifică version, dar celulele
+//	    lor sunt raportate de SnapshotSince până la următoarea versiune
+//	  - Dacă schimbarea a eliminat ultima carte, jocul este marcat ca terminat
+//	    înainte ca listeners să fie notificați (vezi checkGameOver)
+//	  - Operațiile înregistrate cu logOperation sunt scrise în journal cu noua versiune
+func (b *Board) commitChanges(before [][]cardFace) bool {
+	changed := false
+	for i := 0; i < b.Rows; i++ {
+		for j := 0; j < b.Cols; j++ {
+			card := b.Cards[i][j]
+			now := cardFace{Value: card.Value, FaceUp: card.FaceUp, Controller: card.Controller}
+			if before[i][j] == now {
+				continue
+			}
+			b.pendingChanges = append(b.pendingChanges, Position{Row: i, Col: j})
+			if before[i][j].visible() != now.visible() {
+				changed = true
 			}
 		}
 	}
-
-	return result.String()
+	if changed {
+		b.checkGameOver()
+	}
+	if changed || b.turnMoved {
+		// Schimbarea jucătorului activ apare în look (linia "turn"), deci e vizibilă
+		b.turnMoved = false
+		b.publishVersion()
+	}
+	b.flushJournal()
+	return changed
 }
 
 // NotifyListeners notifică toți listeners că tabla s-a modificat
@@ -251,3 +533,239 @@ func (b *Board) NotifyListeners() {
 		}
 	}
 }
+
+// Map înlocuiește fiecare carte de pe tablă cu f(carte), fără să modifice restul stării jocului
+//
+// Specification:
+//
+//	Parameters:
+//	  - playerID: identificatorul jucătorului care aplică map
+//	  - f: funcție matematică de la cărți la cărți (același rezultat pentru aceeași carte)
+//	Returns:
+//	  - string: starea tablei după map, din perspectiva lui playerID
+//	  - error: non-nil dacă f eșuează sau returnează o valoare invalidă de carte;
+//	           cărțile deja înlocuite rămân înlocuite; *SpectatorError dacă
+//	           playerID este spectator
+//	Preconditions:
+//	  - f nu trebuie să folosească board-ul (este apelată fără lock)
+//	Postconditions:
+//	  - Fiecare carte prezentă la început are valoarea f(valoare), dacă nu a fost
+//	    modificată între timp de altă operație
+//	  - FaceUp, Controller și starea jocului a jucătorilor nu se schimbă;
+//	    doar LastAction al lui playerID este actualizat
+//	  - Cărțile care se potrivesc la început după matcher-ul tablei (ex: "a" și "A"
+//	    cu case-insensitive, "2+3" și "5" cu math) sunt înlocuite în același pas
+//	    atomic, deci niciun jucător nu vede nepotrivită o pereche care se potrivea
+//	Thread Safety:
+//	  - f este apelată fără lock; look și flip nu așteaptă după f
+//	  - b.mu este ținut doar cât se înlocuiesc cărțile dintr-un grup de valori
+//	    care se potrivesc
+//	  - Două map-uri concurente se intercalează pe valori, fără deadlock
+//	Effects:
+//	  - Modifică Value-ul cărților din Cards
+//	  - Incrementează version și notifică listeners pentru fiecare grup schimbat
+//	  - Adaugă în journal câte o operație pentru fiecare valoare schimbată
+func (b *Board) Map(playerID string, f func(string) (string, error)) (string, error) {
+	b.mu.Lock()
+	if err := b.checkPlayer(playerID); err != nil {
+		b.mu.Unlock()
+		return "", err
+	}
+	b.GetPlayerState(playerID).LastAction = b.now()
+	b.mu.Unlock()
+
+	// Colectează cărțile prezente acum, grupate după matcher: fiecare valoare
+	// intră în grupul primei valori cu care se potrivește (ca în groupCounts)
+	b.mu.RLock()
+	var groups [][]string
+	positions := make(map[string][]Position)
+	for i := 0; i < b.Rows; i++ {
+		for j := 0; j < b.Cols; j++ {
+			value := b.Cards[i][j].Value
+			if value == "" {
+				continue
+			}
+			if _, seen := positions[value]; !seen {
+				grouped := false
+				for g, group := range groups {
+					if b.matches(group[0], value) {
+						groups[g] = append(group, value)
+						grouped = true
+						break
+					}
+				}
+				if !grouped {
+					groups = append(groups, []string{value})
+				}
+			}
+			positions[value] = append(positions[value], Position{Row: i, Col: j})
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, group := range groups {
+		// Calculează f fără lock, pentru fiecare valoare din grup
+		replacements := make(map[string]string, len(group))
+		for _, from := range group {
+			to, err := f(from)
+			if err != nil {
+				return "", err
+			}
+			if !IsValidCardValue(to) {
+				return "", fmt.Errorf("invalid card %q for %q", to, from)
+			}
+			replacements[from] = to
+		}
+
+		// Înlocuiește atomic cărțile din grup care au încă valoarea de la început;
+		// celelalte au fost modificate între timp de altă operație
+		b.mu.Lock()
+		before := b.faces()
+		for _, from := range group {
+			var cells []Position
+			for _, pos := range positions[from] {
+				card := &b.Cards[pos.Row][pos.Col]
+				if card.Value == from {
+					card.Value = replacements[from]
+					cells = append(cells, pos)
+				}
+			}
+			if len(cells) > 0 {
+				b.logOperation(JournalEntry{Op: OpMap, Player: playerID, From: from, To: replacements[from], Cells: cells})
+			}
+		}
+		if b.commitChanges(before) {
+			for _, from := range group {
+				log.Printf("Map by %s: %s -> %s", playerID, from, replacements[from])
+			}
+		}
+		b.mu.Unlock()
+	}
+	return b.FormatBoard(playerID), nil
+}
+
+// waitForCard așteaptă până când cartea de la pos nu mai este controlată de alt jucător
+// Implementează așteptarea din regula 1-D
+//
+// Specification:
+//
+//	Parameters:
+//	  - ctx: contextul request-ului; anularea lui oprește așteptarea
+//	  - pos: poziția cărții (validă pe tablă)
+//	  - playerID: jucătorul care vrea să întoarcă cartea
+//	Returns:
+//	  - error: nil dacă jucătorul poate încerca flip-ul acum, ctx.Err() dacă ctx a fost anulat,
+//	    ErrGameDeleted dacă tabla a fost închisă (vezi Close)
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - La return apelantul deține din nou b.mu
+//	  - Dacă returnează nil: cartea nu e controlată de altcineva
+//	  - Cererile care așteaptă aceeași carte sunt servite în ordinea sosirii (FIFO)
+//	Thread Safety:
+//	  - Eliberează b.mu cât timp așteaptă, deci alte operații pot continua
+//	Effects:
+//	  - Poate adăuga și scoate waiter-i din b.waiters
+//	  - Scrie în log
+func (b *Board) waitForCard(ctx context.Context, pos Position, playerID string) error {
+	// front devine true după ce am fost trezit: dacă ne-a luat altcineva cartea,
+	// ne întoarcem în capul cozii, nu la coadă
+	front := false
+	for {
+		card := &b.Cards[pos.Row][pos.Col]
+		blocked := card.Controller != "" && card.Controller != playerID
+
+		// Un jucător nou se așază la coadă în spatele celor care așteaptă deja
+		if !blocked && (front || len(b.waiters[pos]) == 0) {
+			return nil
+		}
+
+		log.Printf("Rule 1-D: Card at (%d, %d) is controlled by %s, %s is waiting", pos.Row, pos.Col, card.Controller, playerID)
+		w := b.enqueueWaiter(pos, front)
+
+		b.mu.Unlock()
+		select {
+		case <-w.ch:
+			b.mu.Lock()
+			b.removeWaiter(pos, w)
+			front = true
+		case <-ctx.Done():
+			b.mu.Lock()
+			b.removeWaiter(pos, w)
+			if w.woken {
+				// Am fost trezit dar nu mai folosim cartea: trezește următorul
+				b.wakeWaiter(pos)
+			}
+			return ctx.Err()
+		case <-b.closed:
+			b.mu.Lock()
+			b.removeWaiter(pos, w)
+			return ErrGameDeleted
+		}
+	}
+}
+
+// enqueueWaiter adaugă un waiter nou în coada cărții de la pos
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Returnează waiter-ul adăugat, la începutul cozii dacă front, altfel la sfârșit
+func (b *Board) enqueueWaiter(pos Position, front bool) *cardWaiter {
+	if b.waiters == nil {
+		b.waiters = make(map[Position][]*cardWaiter)
+	}
+	w := &cardWaiter{ch: make(chan struct{})}
+	if front {
+		b.waiters[pos] = append([]*cardWaiter{w}, b.waiters[pos]...)
+	} else {
+		b.waiters[pos] = append(b.waiters[pos], w)
+	}
+	return w
+}
+
+// removeWaiter scoate waiter-ul w din coada cărții de la pos, dacă mai este acolo
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+func (b *Board) removeWaiter(pos Position, w *cardWaiter) {
+	queue := b.waiters[pos]
+	for i, other := range queue {
+		if other == w {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) == 0 {
+		delete(b.waiters, pos)
+	} else {
+		b.waiters[pos] = queue
+	}
+}
+
+// wakeWaiter trezește primul flip care așteaptă cartea de la pos, dacă nu o mai controlează nimeni
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Dacă cartea nu e controlată și primul waiter nu a fost trezit, acesta este trezit
+//	  - Cel mult un waiter este trezit per carte; el îl trezește pe următorul dacă renunță
+//	Effects:
+//	  - Poate închide canalul primului waiter
+func (b *Board) wakeWaiter(pos Position) {
+	queue := b.waiters[pos]
+	if len(queue) == 0 || queue[0].woken {
+		return
+	}
+	if b.Cards[pos.Row][pos.Col].Controller != "" {
+		return
+	}
+	queue[0].woken = true
+	close(queue[0].ch)
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Test Rule 1-A: Flipping empty space fails
@@ -23,7 +27,7 @@ func TestFlipEmptySpace(t *testing.T) {
 	if success {
 		t.Error("Expected flip to fail on empty space")
 	}
-	if playerState.HasFirst {
+	if playerState.InProgress() {
 		t.Error("Player should not have first card after failed flip")
 	}
 }
@@ -53,7 +57,7 @@ func TestFlipFaceDownCard(t *testing.T) {
 	if card.Controller != "player1" {
 		t.Errorf("Card should be controlled by player1, got %s", card.Controller)
 	}
-	if !playerState.HasFirst {
+	if !playerState.InProgress() {
 		t.Error("Player should have first card")
 	}
 }
@@ -80,7 +84,7 @@ func TestTakeControlOfFaceUpCard(t *testing.T) {
 	if card.Controller != "player1" {
 		t.Errorf("Card should be controlled by player1, got %s", card.Controller)
 	}
-	if !playerState.HasFirst {
+	if !playerState.InProgress() {
 		t.Error("Player should have first card")
 	}
 }
@@ -104,7 +108,7 @@ func TestCannotFlipControlledCard(t *testing.T) {
 	if success {
 		t.Error("Expected flip to fail on card controlled by another player")
 	}
-	if playerState.HasFirst {
+	if playerState.InProgress() {
 		t.Error("Player should not have first card after failed flip")
 	}
 }
@@ -120,11 +124,7 @@ func TestMatchingCards(t *testing.T) {
 		},
 	}
 
-	playerState := &PlayerState{
-		HasFirst:     true,
-		FirstCardRow: 0,
-		FirstCardCol: 0,
-	}
+	playerState := &PlayerState{Held: []Position{{Row: 0, Col: 0}}}
 	card := &board.Cards[0][1]
 
 	success := FlipSecondCard(board, card, 0, 1, "player1", playerState)
@@ -135,7 +135,7 @@ func TestMatchingCards(t *testing.T) {
 	if !playerState.Matched {
 		t.Error("Cards should be matched")
 	}
-	if !playerState.HasSecond {
+	if !playerState.Done || len(playerState.Held) != 2 {
 		t.Error("Player should have second card")
 	}
 	if card.Controller != "player1" {
@@ -154,11 +154,7 @@ func TestNonMatchingCards(t *testing.T) {
 		},
 	}
 
-	playerState := &PlayerState{
-		HasFirst:     true,
-		FirstCardRow: 0,
-		FirstCardCol: 0,
-	}
+	playerState := &PlayerState{Held: []Position{{Row: 0, Col: 0}}}
 	card := &board.Cards[0][1]
 
 	success := FlipSecondCard(board, card, 0, 1, "player1", playerState)
@@ -169,7 +165,7 @@ func TestNonMatchingCards(t *testing.T) {
 	if playerState.Matched {
 		t.Error("Cards should not be matched")
 	}
-	if !playerState.HasSecond {
+	if !playerState.Done || len(playerState.Held) != 2 {
 		t.Error("Player should have second card")
 	}
 
@@ -194,13 +190,9 @@ func TestRemoveMatchedCards(t *testing.T) {
 	}
 
 	playerState := &PlayerState{
-		HasFirst:      false,
-		HasSecond:     true,
-		Matched:       true,
-		FirstCardRow:  0,
-		FirstCardCol:  0,
-		SecondCardRow: 0,
-		SecondCardCol: 1,
+		Held:    []Position{{Row: 0, Col: 0}, {Row: 0, Col: 1}},
+		Done:    true,
+		Matched: true,
 	}
 
 	CleanupPreviousPlay(board, playerState, "player1")
@@ -214,7 +206,7 @@ func TestRemoveMatchedCards(t *testing.T) {
 	if card2.Value != "" {
 		t.Error("Second matched card should be removed")
 	}
-	if playerState.HasSecond {
+	if len(playerState.Held) > 0 || playerState.Done {
 		t.Error("Player state should be reset")
 	}
 }
@@ -231,13 +223,9 @@ func TestTurnDownNonMatched(t *testing.T) {
 	}
 
 	playerState := &PlayerState{
-		HasFirst:      false,
-		HasSecond:     true,
-		Matched:       false,
-		FirstCardRow:  0,
-		FirstCardCol:  0,
-		SecondCardRow: 0,
-		SecondCardCol: 1,
+		Held:    []Position{{Row: 0, Col: 0}, {Row: 0, Col: 1}},
+		Done:    true,
+		Matched: false,
 	}
 
 	CleanupPreviousPlay(board, playerState, "player1")
@@ -251,7 +239,7 @@ func TestTurnDownNonMatched(t *testing.T) {
 	if card2.FaceUp {
 		t.Error("Second non-matched card should be face-down")
 	}
-	if playerState.HasSecond {
+	if len(playerState.Held) > 0 || playerState.Done {
 		t.Error("Player state should be reset")
 	}
 }
@@ -267,11 +255,7 @@ func TestFlipSecondCardEmptySpace(t *testing.T) {
 		},
 	}
 
-	playerState := &PlayerState{
-		HasFirst:     true,
-		FirstCardRow: 0,
-		FirstCardCol: 0,
-	}
+	playerState := &PlayerState{Held: []Position{{Row: 0, Col: 0}}}
 	card := &board.Cards[0][1]
 
 	success := FlipSecondCard(board, card, 0, 1, "player1", playerState)
@@ -279,7 +263,7 @@ func TestFlipSecondCardEmptySpace(t *testing.T) {
 	if success {
 		t.Error("Expected flip to fail on empty space")
 	}
-	if playerState.HasFirst {
+	if playerState.InProgress() {
 		t.Error("Player should relinquish first card after failed second flip")
 	}
 
@@ -300,11 +284,7 @@ func TestFlipSecondCardControlled(t *testing.T) {
 		},
 	}
 
-	playerState := &PlayerState{
-		HasFirst:     true,
-		FirstCardRow: 0,
-		FirstCardCol: 0,
-	}
+	playerState := &PlayerState{Held: []Position{{Row: 0, Col: 0}}}
 	card := &board.Cards[0][1]
 
 	success := FlipSecondCard(board, card, 0, 1, "player1", playerState)
@@ -312,14 +292,14 @@ func TestFlipSecondCardControlled(t *testing.T) {
 	if success {
 		t.Error("Expected flip to fail on controlled card")
 	}
-	if playerState.HasFirst {
+	if playerState.InProgress() {
 		t.Error("Player should relinquish first card after failed second flip")
 	}
 }
 
 // Test loading board from file
 func TestLoadBoardFromFile(t *testing.T) {
-	board, err := LoadBoardFromFile("perfect.txt")
+	board, err := LoadBoardFromFile("perfect.txt", ParseOptions{GroupSize: 2})
 	if err != nil {
 		t.Fatalf("Failed to load board: %v", err)
 	}
@@ -346,3 +326,641 @@ func TestLoadBoardFromFile(t *testing.T) {
 		}
 	}
 }
+
+// flipAsync pornește FlipCard într-o goroutine și returnează canalul cu rezultatul
+func flipAsync(ctx context.Context, board *Board, row, col int, playerID string) <-chan bool {
+	done := make(chan bool, 1)
+	go func() {
+		done <- FlipCard(ctx, board, row, col, playerID) == nil
+	}()
+	return done
+}
+
+// waitForWaiters așteaptă până când în coada cărții sunt n flip-uri
+func waitForWaiters(t *testing.T, board *Board, pos Position, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		board.mu.RLock()
+		count := len(board.waiters[pos])
+		board.mu.RUnlock()
+		if count == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d waiters at %v", n, pos)
+}
+
+// Test Rule 1-D: Flip waits until the controlled card is relinquished
+func TestFlipWaitsForControlledCard(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+		{NewCard("C"), NewCard("D")},
+	})
+	ctx := context.Background()
+
+	if err := FlipCard(ctx, board, 0, 0, "player2"); err != nil {
+		t.Fatal("player2 should control (0,0)")
+	}
+
+	done := flipAsync(ctx, board, 0, 0, "player1")
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 1)
+
+	select {
+	case <-done:
+		t.Fatal("Flip should wait while another player controls the card")
+	default:
+	}
+
+	// player2 nu găsește pereche și eliberează (0,0)
+	if err := FlipCard(ctx, board, 0, 1, "player2"); err != nil {
+		t.Fatal("player2 second flip should succeed")
+	}
+
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Error("Waiting flip should succeed after card is relinquished")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiting flip was not woken up")
+	}
+	if board.Cards[0][0].Controller != "player1" {
+		t.Errorf("Card should be controlled by player1, got %s", board.Cards[0][0].Controller)
+	}
+}
+
+// Test Rule 1-D: Flip fails after waiting if the card is removed
+func TestFlipWaitFailsWhenCardRemoved(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A")},
+		{NewCard("C"), NewCard("D")},
+	})
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player2")
+	FlipCard(ctx, board, 0, 1, "player2")
+
+	done := flipAsync(ctx, board, 0, 0, "player1")
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 1)
+
+	// Următorul flip al lui player2 elimină perechea (regula 3-A)
+	FlipCard(ctx, board, 1, 0, "player2")
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("Waiting flip should fail once the card is removed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiting flip was not woken up")
+	}
+}
+
+// Test Rule 1-D: Waiting flips are served in FIFO order
+func TestFlipWaitIsFIFO(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+		{NewCard("C"), NewCard("D")},
+	})
+	ctx := context.Background()
+	pos := Position{Row: 0, Col: 0}
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	first := flipAsync(ctx, board, 0, 0, "player2")
+	waitForWaiters(t, board, pos, 1)
+	second := flipAsync(ctx, board, 0, 0, "player3")
+	waitForWaiters(t, board, pos, 2)
+
+	// player1 eliberează cartea: player2 trebuie servit primul
+	FlipCard(ctx, board, 0, 1, "player1")
+
+	select {
+	case ok := <-first:
+		if !ok {
+			t.Fatal("First waiter should get the card")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("First waiter was not woken up")
+	}
+	select {
+	case <-second:
+		t.Fatal("Second waiter should still wait while player2 controls the card")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// player2 eliberează cartea: acum e rândul lui player3
+	FlipCard(ctx, board, 0, 1, "player2")
+	select {
+	case ok := <-second:
+		if !ok {
+			t.Error("Second waiter should get the card")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Second waiter was not woken up")
+	}
+}
+
+// Test Rule 1-D: A cancelled request stops waiting and leaves the queue
+func TestFlipWaitCancelled(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+		{NewCard("C"), NewCard("D")},
+	})
+	pos := Position{Row: 0, Col: 0}
+
+	FlipCard(context.Background(), board, 0, 0, "player2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- FlipCard(ctx, board, 0, 0, "player1")
+	}()
+	waitForWaiters(t, board, pos, 1)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected context error after cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Cancelled flip did not return")
+	}
+	waitForWaiters(t, board, pos, 0)
+	if board.GetPlayerState("player1").InProgress() {
+		t.Error("Cancelled flip should not give player1 a card")
+	}
+}
+
+// Test Map: every card is replaced by f(card), other state is kept
+func TestMapReplacesEveryCard(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+		{NewCard("B"), Card{Value: "", FaceUp: false, Controller: ""}},
+	})
+	FlipCard(context.Background(), board, 0, 0, "player1")
+
+	_, err := board.Map("player1", func(card string) (string, error) {
+		return card + card, nil
+	})
+	if err != nil {
+		t.Fatalf("Map failed: %v", err)
+	}
+
+	expected := [][]string{{"AA", "BB"}, {"BB", ""}}
+	for i := range expected {
+		for j := range expected[i] {
+			if board.Cards[i][j].Value != expected[i][j] {
+				t.Errorf("Card at (%d,%d): expected %q, got %q", i, j, expected[i][j], board.Cards[i][j].Value)
+			}
+		}
+	}
+	if board.Cards[0][0].Controller != "player1" || !board.Cards[0][0].FaceUp {
+		t.Error("Map should not change control or face-up state")
+	}
+}
+
+// Test Map: f is applied once per card even if it maps onto another card's value
+func TestMapAppliesFunctionOnce(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+	})
+
+	board.Map("player1", func(card string) (string, error) {
+		return map[string]string{"A": "B", "B": "C"}[card], nil
+	})
+
+	if board.Cards[0][0].Value != "B" || board.Cards[0][1].Value != "C" {
+		t.Errorf("Expected B C, got %s %s", board.Cards[0][0].Value, board.Cards[0][1].Value)
+	}
+}
+
+// Test Map: look is not blocked while f runs and matching cards stay matching
+func TestMapIsPairwiseConsistent(t *testing.T) {
+	caseInsensitive, err := ParseMatcher("case-insensitive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		matcher Matcher
+		cards   [][]Card
+	}{
+		{"exact", nil, [][]Card{{NewCard("A"), NewCard("B")}, {NewCard("A"), NewCard("B")}}},
+		{"case-insensitive", caseInsensitive, [][]Card{{NewCard("a"), NewCard("B")}, {NewCard("A"), NewCard("b")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := NewBoard(tt.cards)
+			board.SetMatcher(tt.matcher)
+
+			inF := make(chan struct{})
+			release := make(chan struct{})
+			done := make(chan struct{})
+			var once sync.Once
+			go func() {
+				board.Map("player1", func(card string) (string, error) {
+					if strings.EqualFold(card, "B") {
+						once.Do(func() {
+							close(inF)
+							<-release
+						})
+					}
+					return card + "2", nil
+				})
+				close(done)
+			}()
+
+			<-inF
+			// f rulează: look trebuie să răspundă, iar perechile să fie consistente
+			board.FormatBoard("player2")
+			board.mu.RLock()
+			if !board.matches(board.Cards[0][0].Value, board.Cards[1][0].Value) ||
+				!board.matches(board.Cards[0][1].Value, board.Cards[1][1].Value) {
+				t.Errorf("Matching cards observed as non-matching during map: %v", board.Cards)
+			}
+			board.mu.RUnlock()
+			close(release)
+			<-done
+
+			if !strings.EqualFold(board.Cards[0][1].Value, "B2") || !strings.EqualFold(board.Cards[1][1].Value, "B2") {
+				t.Error("Map should replace both B cards")
+			}
+		})
+	}
+}
+
+// Test Map: a card changed by another operation while f runs keeps its new value
+func TestMapSkipsCardsChangedMeanwhile(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+	})
+
+	inF := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		board.Map("player1", func(card string) (string, error) {
+			if card == "A" {
+				close(inF)
+				<-release
+				return "C", nil
+			}
+			return "D", nil
+		})
+		close(done)
+	}()
+
+	<-inF
+	// Cât timp primul map calculează f("A"), B devine A
+	if _, err := board.Map("player2", func(card string) (string, error) {
+		return "A", nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	<-done
+
+	if board.Cards[0][0].Value != "C" {
+		t.Errorf("Expected the first card to become C, got %s", board.Cards[0][0].Value)
+	}
+	if board.Cards[0][1].Value != "A" {
+		t.Errorf("Expected the card changed meanwhile to stay A, got %s", board.Cards[0][1].Value)
+	}
+}
+
+// Test Map: two concurrent maps do not wait for each other
+func TestConcurrentMapsInterleave(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A")},
+	})
+
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	first := make(chan error, 1)
+	var once sync.Once
+	go func() {
+		_, err := board.Map("player1", func(card string) (string, error) {
+			once.Do(func() { close(blocked) })
+			<-release
+			return card, nil
+		})
+		first <- err
+	}()
+	<-blocked
+
+	// Al doilea map trebuie să se termine cât timp primul așteaptă în f
+	if _, err := board.Map("player2", func(card string) (string, error) {
+		return "X", nil
+	}); err != nil {
+		t.Fatalf("Second map failed: %v", err)
+	}
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("First map failed: %v", err)
+	}
+	if board.Cards[0][0].Value != board.Cards[0][1].Value {
+		t.Error("Concurrent maps broke a matching pair")
+	}
+}
+
+// Test Map: invalid replacement cards are rejected
+func TestMapRejectsInvalidCard(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A")},
+	})
+
+	if _, err := board.Map("player1", func(card string) (string, error) {
+		return "two words", nil
+	}); err == nil {
+		t.Error("Expected error for card with whitespace")
+	}
+	if board.Cards[0][0].Value != "A" {
+		t.Error("Invalid map should not change cards")
+	}
+}
+
+// Test watch: taking control of a face-up card is not a visible change
+func TestControlOnlyChangeKeepsVersion(t *testing.T) {
+	board := NewBoard([][]Card{
+		{Card{Value: "A", FaceUp: true, Controller: ""}, NewCard("B")},
+	})
+
+	if err := FlipCard(context.Background(), board, 0, 0, "player1"); err != nil {
+		t.Fatal("Expected rule 1-C flip to succeed")
+	}
+	if board.Version() != 0 {
+		t.Errorf("Control-only change should not bump version, got %d", board.Version())
+	}
+
+	FlipCard(context.Background(), board, 0, 1, "player1")
+	if board.Version() != 1 {
+		t.Errorf("Turning a card up should bump version to 1, got %d", board.Version())
+	}
+}
+
+// Test watch: WaitForChange wakes only on a visible change
+func TestWaitForChangeIgnoresNoOps(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+	})
+
+	done := make(chan int, 1)
+	go func() {
+		version, _ := board.WaitForChange(context.Background(), 0)
+		done <- version
+	}()
+
+	// Înlocuirea unei valori cu ea însăși nu e o schimbare
+	board.Map("player1", func(card string) (string, error) { return card, nil })
+	select {
+	case <-done:
+		t.Fatal("Watch should ignore a no-op map")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	FlipCard(context.Background(), board, 0, 0, "player1")
+	select {
+	case version := <-done:
+		if version != 1 {
+			t.Errorf("Expected version 1, got %d", version)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch was not woken by a visible change")
+	}
+}
+
+// Test watch: resuming with an old version returns immediately
+func TestWaitForChangeSinceOldVersion(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+	})
+	FlipCard(context.Background(), board, 0, 0, "player1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	version, err := board.WaitForChange(ctx, 0)
+	if err != nil || version != 1 {
+		t.Errorf("Expected version 1 without waiting, got %d, %v", version, err)
+	}
+
+	if _, err := board.WaitForChange(ctx, 1); err == nil {
+		t.Error("Expected timeout when nothing changed since version 1")
+	}
+}
+
+// Test scores: removed pairs and failed flips are counted per player
+func TestScoresCountPairsAndFailedFlips(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A"), NewCard("B")},
+	})
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 1, "player1")
+	FlipCard(ctx, board, 0, 2, "player1") // 3-A: perechea A este colectată
+
+	if err := FlipCard(ctx, board, 0, 0, "player2"); err == nil {
+		t.Fatal("Expected rule 1-A failure")
+	}
+
+	scores := board.Scores()
+	if len(scores) != 2 {
+		t.Fatalf("Expected 2 players, got %d", len(scores))
+	}
+	if scores[0].PlayerID != "player1" || scores[0].Pairs != 1 || scores[0].FailedFlips != 0 {
+		t.Errorf("Unexpected score for leader: %+v", scores[0])
+	}
+	if scores[1].PlayerID != "player2" || scores[1].Pairs != 0 || scores[1].FailedFlips != 1 {
+		t.Errorf("Unexpected score for player2: %+v", scores[1])
+	}
+	if scores[1].LastAction.IsZero() {
+		t.Error("LastAction should be set after a flip")
+	}
+}
+
+func TestLastPairEndsGame(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+		{NewCard("B"), NewCard("A")},
+	})
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 1, 1, "player1")
+	FlipCard(ctx, board, 0, 1, "player2")
+	FlipCard(ctx, board, 1, 0, "player2")
+
+	if over, _ := board.GameOver(); over {
+		t.Fatal("Game should not be over while cards remain")
+	}
+
+	// 3-A: perechea A a lui player1 este eliminată, iar perechea B a lui player2
+	// este ultima, deci e eliminată fără ca player2 să mai facă un flip
+	if err := FlipCard(ctx, board, 0, 0, "player1"); err == nil {
+		t.Fatal("Expected rule 1-A failure on the removed card")
+	}
+
+	over, winners := board.GameOver()
+	if !over {
+		t.Fatal("Game should be over after the last pair is matched")
+	}
+	if len(winners) != 2 || winners[0] != "player1" || winners[1] != "player2" {
+		t.Errorf("Expected a tie between player1 and player2, got %v", winners)
+	}
+	expected := "2x2\nnone\nnone\nnone\nnone\nover player1 player2\n"
+	if got := board.FormatBoard("player1"); got != expected {
+		t.Errorf("Expected final board %q, got %q", expected, got)
+	}
+}
+
+func TestAutoResetReloadsLayout(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A")},
+	})
+	board.SetAutoReset(ResetReload, 10*time.Millisecond)
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 1, "player1")
+	over, winners := board.GameOver()
+	if !over || len(winners) != 1 || winners[0] != "player1" {
+		t.Fatalf("Expected player1 to win, got over=%v winners=%v", over, winners)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := board.WaitForChange(waitCtx, board.Version()); err != nil {
+		t.Fatalf("Board was not reset: %v", err)
+	}
+
+	if over, _ := board.GameOver(); over {
+		t.Error("Game should not be over after reset")
+	}
+	if got := board.FormatBoard("player1"); got != "1x2\ndown\ndown\n" {
+		t.Errorf("Expected the original layout face down, got %q", got)
+	}
+	if scores := board.Scores(); scores[0].Pairs != 0 {
+		t.Errorf("Scores should restart after reset, got %+v", scores[0])
+	}
+	if err := FlipCard(ctx, board, 0, 0, "player1"); err != nil {
+		t.Errorf("Flip after reset should succeed: %v", err)
+	}
+}
+
+func TestResetShuffleKeepsCards(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B"), NewCard("A"), NewCard("B")},
+	})
+	board.Reset(true)
+
+	counts := map[string]int{}
+	for _, card := range board.Cards[0] {
+		counts[card.Value]++
+	}
+	if counts["A"] != 2 || counts["B"] != 2 {
+		t.Errorf("Shuffle should keep the same cards, got %v", counts)
+	}
+}
+
+func TestSnapshotSinceReturnsChangedCells(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+		{NewCard("A"), NewCard("B")},
+	})
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 1, 1, "player1") // 2-E: ambele rămân cu fața în sus
+	FlipCard(ctx, board, 0, 0, "player2") // 1-C: doar controlul se schimbă
+
+	snapshot := board.SnapshotSince("player2", 1)
+	if !snapshot.Delta || snapshot.Version != 2 {
+		t.Fatalf("Expected a delta at version 2, got %+v", snapshot)
+	}
+	expected := "2x2\ndelta\n0,0 my A\n1,1 up B\n"
+	if got := snapshot.Text(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	// Schimbarea de control apare și pentru un client aflat deja la versiunea curentă
+	if got := board.SnapshotSince("player2", 2).Text(); got != "2x2\ndelta\n0,0 my A\n" {
+		t.Errorf("Expected the control change, got %q", got)
+	}
+}
+
+func TestSnapshotSinceFallsBackWhenTooOld(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("B")},
+	})
+	for i := 0; i <= maxChangeLog; i++ {
+		board.Map("player1", func(card string) (string, error) {
+			if card == "A" {
+				return "C", nil
+			}
+			return "A", nil
+		})
+	}
+
+	if snapshot := board.SnapshotSince("player1", 0); snapshot.Delta || len(snapshot.Cells) != 2 {
+		t.Errorf("Expected a full snapshot for a version no longer in the log, got %+v", snapshot)
+	}
+	if snapshot := board.SnapshotSince("player1", board.Version()+1); snapshot.Delta {
+		t.Error("Expected a full snapshot for a version from the future")
+	}
+	if snapshot := board.SnapshotSince("player1", board.Version()-1); !snapshot.Delta {
+		t.Error("Expected a delta for a recent version")
+	}
+}
+
+// Test groups of three: the group is complete only after the third matching card
+func TestGroupOfThree(t *testing.T) {
+	board, err := ParseBoard(strings.NewReader("#group 3\n2x3\nA\nB\nA\nB\nA\nB\n"), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 2, "player1") // 2-D, grupul nu e încă complet
+	if state := board.GetPlayerState("player1"); !state.InProgress() || len(state.Held) != 2 {
+		t.Fatalf("Expected the turn to continue after two of three cards, got %+v", state)
+	}
+	FlipCard(ctx, board, 1, 1, "player1") // 2-D, grup complet
+	if state := board.GetPlayerState("player1"); !state.Done || !state.Matched {
+		t.Fatalf("Expected a complete group, got %+v", state)
+	}
+
+	FlipCard(ctx, board, 0, 1, "player1") // 3-A, apoi 1-B
+	for _, pos := range []Position{{Row: 0, Col: 0}, {Row: 0, Col: 2}, {Row: 1, Col: 1}} {
+		if board.Cards[pos.Row][pos.Col].Value != "" {
+			t.Errorf("Expected the card at %v to be removed", pos)
+		}
+	}
+	if scores := board.Scores(); len(scores) != 1 || scores[0].Pairs != 1 {
+		t.Errorf("Expected one collected group, got %+v", scores)
+	}
+}
+
+// Test that a mismatch in a group of three relinquishes every card of the turn
+func TestGroupOfThreeMismatch(t *testing.T) {
+	board, err := ParseBoard(strings.NewReader("#group 3\n2x3\nA\nB\nA\nB\nA\nB\n"), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 2, "player1")
+	FlipCard(ctx, board, 0, 1, "player1") // 2-E
+	if got := board.FormatBoard("player1"); got != "2x3\nup A\nup B\nup A\ndown\ndown\ndown\n" {
+		t.Errorf("Expected all three cards up and relinquished, got %q", got)
+	}
+
+	FlipCard(ctx, board, 1, 0, "player1") // 3-B, apoi 1-B
+	if got := board.FormatBoard("player1"); got != "2x3\ndown\ndown\ndown\nmy B\ndown\ndown\n" {
+		t.Errorf("Expected the mismatched cards to be turned down, got %q", got)
+	}
+}
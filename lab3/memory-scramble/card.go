@@ -1,5 +1,7 @@
 package main
 
+import "unicode"
+
 // Card reprezintă o carte din jocul Memory Scramble
 // Representation Invariants:
 //   - Dacă Value == "" atunci FaceUp == false și Controller == ""
@@ -10,6 +12,24 @@ type Card struct {
 	Controller string // ID-ul jucătorului care o controlează sau ""
 }
 
+// NewCard creează o carte nouă cu o valoare dată
+//
+// Specification:
+//
+//	Parameters:
+//	  - value: valoarea cărții (nu trebuie să fie "")
+//	Returns:
+//	  - Card: carte cu fața în jos și necontrolată
+//	Postconditions:
+//	  - Cartea returnată respectă representation invariants
+func NewCard(value string) Card {
+	return Card{
+		Value:      value,
+		FaceUp:     false,
+		Controller: "",
+	}
+}
+
 // checkRep verifică representation invariants pentru Card
 //
 // Specification:
@@ -32,3 +52,23 @@ func (c *Card) checkRep() {
 		panic("Controlled card must be face-up")
 	}
 }
+
+// IsValidCardValue verifică dacă un string poate fi valoarea unei cărți
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă value e nevid și nu conține whitespace
+//	Postconditions:
+//	  - O valoare validă apare ca un singur cuvânt în formatul "up X" / "my X"
+func IsValidCardValue(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
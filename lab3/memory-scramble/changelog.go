@@ -0,0 +1,89 @@
+package main
+
+import "sort"
+
+// maxChangeLog este numărul maxim de versiuni păstrate în change log
+const maxChangeLog = 256
+
+// changeEntry reține celulele schimbate la o versiune a tablei
+type changeEntry struct {
+	version int        // Versiunea la care s-au produs schimbările
+	cells   []Position // Celulele schimbate (inclusiv doar ca control) față de versiunea anterioară
+}
+
+// publishVersion incrementează versiunea, o adaugă în change log și notifică listeners
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - version crește cu 1
+//	  - changes se termină cu o intrare pentru noua versiune, cu pendingChanges
+//	    (fără duplicate); pendingChanges este golit
+//	  - Dacă changes depășește maxChangeLog, cea mai veche intrare este eliminată
+//	    și changeLogBase avansează
+//	  - Toți listeners sunt notificați
+func (b *Board) publishVersion() {
+	b.version++
+
+	seen := make(map[Position]bool)
+	var cells []Position
+	for _, pos := range b.pendingChanges {
+		if !seen[pos] {
+			seen[pos] = true
+			cells = append(cells, pos)
+		}
+	}
+	b.pendingChanges = nil
+
+	b.changes = append(b.changes, changeEntry{version: b.version, cells: cells})
+	if len(b.changes) > maxChangeLog {
+		b.changeLogBase = b.changes[0].version
+		b.changes = b.changes[1:]
+	}
+
+	b.NotifyListeners()
+}
+
+// changedSince returnează celulele schimbate după versiunea since
+//
+// Specification:
+//
+//	Returns:
+//	  - []Position: celulele schimbate în versiunile (since, version] și cele
+//	    schimbate doar ca control de atunci, sortate după rând și coloană
+//	  - bool: false dacă change log-ul nu mai acoperă since (prea veche sau din viitor)
+//	Preconditions:
+//	  - Apelantul deține b.mu
+func (b *Board) changedSince(since int) ([]Position, bool) {
+	if since < b.changeLogBase || since > b.version {
+		return nil, false
+	}
+
+	seen := make(map[Position]bool)
+	cells := []Position{}
+	add := func(pos Position) {
+		if !seen[pos] {
+			seen[pos] = true
+			cells = append(cells, pos)
+		}
+	}
+	// changes[k] are versiunea changeLogBase+k+1
+	for _, entry := range b.changes[since-b.changeLogBase:] {
+		for _, pos := range entry.cells {
+			add(pos)
+		}
+	}
+	for _, pos := range b.pendingChanges {
+		add(pos)
+	}
+
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Row != cells[j].Row {
+			return cells[i].Row < cells[j].Row
+		}
+		return cells[i].Col < cells[j].Col
+	})
+	return cells, true
+}
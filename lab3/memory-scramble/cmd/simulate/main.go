@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stats collects the outcome of every flip made by the simulated players.
+// All fields are protected by mu.
+type stats struct {
+	mu        sync.Mutex
+	flips     int             // successful flips (200)
+	conflicts int             // flips rejected by the rules (409)
+	errors    int             // transport errors and unexpected statuses
+	latencies []time.Duration // latency of every completed request
+}
+
+// record adds the outcome of one flip request.
+func (s *stats) record(status int, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case err != nil:
+		s.errors++
+		return
+	case status == http.StatusOK:
+		s.flips++
+	case status == http.StatusConflict:
+		s.conflicts++
+	default:
+		s.errors++
+	}
+	s.latencies = append(s.latencies, latency)
+}
+
+// percentile returns the p-th percentile (0-100) of sorted latencies, or 0 if there are none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(float64(len(sorted)-1) * p / 100)
+	return sorted[index]
+}
+
+func main() {
+	server := flag.String("server", "http://localhost:8080", "base URL of the Memory Scramble server")
+	players := flag.Int("players", 4, "number of simulated players")
+	moves := flag.Int("moves", 100, "flips made by each player")
+	minDelay := flag.Duration("min-delay", 100*time.Microsecond, "minimum delay between two flips of a player")
+	maxDelay := flag.Duration("max-delay", 2*time.Millisecond, "maximum delay between two flips of a player")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of a single request")
+	flag.Parse()
+
+	if *players < 1 || *moves < 0 || *minDelay < 0 || *maxDelay < *minDelay {
+		fmt.Fprintln(os.Stderr, "invalid flags: need players >= 1, moves >= 0, 0 <= min-delay <= max-delay")
+		os.Exit(2)
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	baseURL := strings.TrimSuffix(*server, "/")
+
+	rows, cols, err := boardSize(client, baseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read board size: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Starting simulation on a %dx%d board with %d players, %d moves each...\n", rows, cols, *players, *moves)
+	fmt.Printf("Delays: %v - %v\n", *minDelay, *maxDelay)
+
+	var results stats
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for i := 1; i <= *players; i++ {
+		wg.Add(1)
+		go func(p string) {
+			defer wg.Done()
+			simulatePlayer(client, baseURL, p, rows, cols, *moves, *minDelay, *maxDelay, &results)
+		}(fmt.Sprintf("player%d", i))
+	}
+
+	wg.Wait()
+	printSummary(&results, time.Since(start))
+}
+
+// boardSize reads the dimensions from the first line of /look/.
+func boardSize(client *http.Client, baseURL string) (int, int, error) {
+	resp, err := client.Get(baseURL + "/look/simulator")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("look returned %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	if !scanner.Scan() {
+		return 0, 0, fmt.Errorf("empty look response")
+	}
+	var rows, cols int
+	if _, err := fmt.Sscanf(scanner.Text(), "%dx%d", &rows, &cols); err != nil || rows < 1 || cols < 1 {
+		return 0, 0, fmt.Errorf("invalid board header %q", scanner.Text())
+	}
+	return rows, cols, nil
+}
+
+func simulatePlayer(client *http.Client, baseURL, playerID string, rows, cols, moves int,
+	minDelay, maxDelay time.Duration, results *stats) {
+	for i := 0; i < moves; i++ {
+		// Random delay between minDelay and maxDelay
+		time.Sleep(minDelay + time.Duration(rand.Int63n(int64(maxDelay-minDelay)+1)))
+
+		// Random position
+		row := rand.Intn(rows)
+		col := rand.Intn(cols)
+
+		// Make flip request
+		url := fmt.Sprintf("%s/flip/%s/%d,%d", baseURL, playerID, row, col)
+		started := time.Now()
+		resp, err := client.Get(url)
+		if err != nil {
+			results.record(0, 0, err)
+			fmt.Printf("[%s] Error on move %d: %v\n", playerID, i+1, err)
+			continue
+		}
+
+		// Read and discard response body
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		results.record(resp.StatusCode, time.Since(started), nil)
+
+		// Print progress every 25 moves
+		if (i+1)%25 == 0 {
+			fmt.Printf("[%s] Completed %d/%d moves\n", playerID, i+1, moves)
+		}
+	}
+	fmt.Printf("[%s] Finished all %d moves\n", playerID, moves)
+}
+
+// printSummary prints the totals and latency percentiles of the simulation.
+func printSummary(results *stats, elapsed time.Duration) {
+	results.mu.Lock()
+	defer results.mu.Unlock()
+
+	sorted := append([]time.Duration(nil), results.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	fmt.Println("\nSimulation complete")
+	fmt.Printf("  duration:  %v\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("  flips:     %d\n", results.flips)
+	fmt.Printf("  conflicts: %d\n", results.conflicts)
+	fmt.Printf("  errors:    %d\n", results.errors)
+	fmt.Printf("  latency:   p50=%v p90=%v p99=%v max=%v\n",
+		percentile(sorted, 50), percentile(sorted, 90), percentile(sorted, 99), percentile(sorted, 100))
+}
@@ -1,6 +1,184 @@
 package main
 
-import "log"
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// RuleError descrie un flip care a eșuat conform unei reguli din specificația jocului
+type RuleError struct {
+	Rule string // Regula care a eșuat: "1-A", "1-D", "2-A" sau "2-B"
+	Row  int    // Rândul cărții
+	Col  int    // Coloana cărții
+}
+
+// Error implementează interfața error
+func (e *RuleError) Error() string {
+	var reason string
+	switch e.Rule {
+	case "1-A", "2-A":
+		reason = "no card"
+	case "1-D", "2-B":
+		reason = "card is controlled by a player"
+	default:
+		reason = "cannot flip card"
+	}
+	return fmt.Sprintf("Rule %s: %s at (%d, %d)", e.Rule, reason, e.Row, e.Col)
+}
+
+// FlipCard execută un flip complet pentru un jucător, cu lock-urile necesare
+// Alege între prima carte și următoarele în funcție de starea jucătorului
+//
+// Specification:
+//
+//	Parameters:
+//	  - ctx: contextul request-ului; anularea lui oprește așteptarea din regula 1-D
+//	  - board: pointer către Board (nu trebuie nil)
+//	  - row, col: coordonatele cărții
+//	  - playerID: identificatorul jucătorului
+//	Returns:
+//	  - error: nil dacă flip-ul reușește
+//	           *RuleError dacă flip-ul eșuează conform regulilor (1-A, 1-D, 2-A, 2-B)
+//	           *TurnError dacă jocul e pe ture și alt jucător este la rând
+//	           *SpectatorError dacă playerID este spectator (vezi Board.Spectate)
+//	           ctx.Err() dacă ctx a fost anulat în timpul așteptării
+//	Preconditions:
+//	  - board != nil
+//	  - 0 <= row < board.Rows
+//	  - 0 <= col < board.Cols
+//	Postconditions:
+//	  - În modul pe ture, jucătorul intră în ordinea turelor dacă nu era; dacă nu e
+//	    rândul lui, flip-ul este refuzat fără să modifice tabla
+//	  - Dacă jucătorul nu are o tură în curs, tura anterioară este curățată (regulile 3-A, 3-B)
+//	  - Dacă cartea e controlată de alt jucător, flip-ul așteaptă (regula 1-D)
+//	    până când cartea este eliberată sau eliminată, apoi reușește sau eșuează;
+//	    în modul pe ture nu se așteaptă (celălalt jucător nu poate juca), flip-ul eșuează
+//	  - Dacă vreo carte s-a întors sau a fost eliminată, board.version este incrementat
+//	    și listeners sunt notificați (schimbările doar de control nu contează)
+//	  - LastAction al jucătorului este actualizat; FailedFlips crește dacă flip-ul eșuează
+//	  - Dacă pe tablă a rămas doar un grup potrivit, el este eliminat
+//	    imediat și jocul se termină (vezi Board.GameOver)
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește board.mu)
+//	  - board.mu nu este ținut în timpul așteptării
+//	Effects:
+//	  - Modifică board.Cards și starea jucătorului conform regulilor
+//	  - Notifică listeners
+func FlipCard(ctx context.Context, board *Board, row, col int, playerID string) error {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	// Spectatorii doar privesc
+	if err := board.checkPlayer(playerID); err != nil {
+		return err
+	}
+
+	playerState := board.GetPlayerState(playerID)
+	playerState.LastAction = board.now()
+	pos := Position{Row: row, Col: col}
+
+	// Modul pe ture: doar jucătorul activ poate întoarce cărți
+	if err := board.checkTurn(playerID); err != nil {
+		board.commitTurn()
+		return err
+	}
+
+	before := board.faces()
+	var rule string
+	var ok bool
+	if !playerState.InProgress() {
+		// Curăță tura anterioară înainte de a începe una nouă
+		CleanupPreviousPlay(board, playerState, playerID)
+
+		// Publică cleanup-ul acum: tabla se poate schimba cât timp așteptăm
+		board.commitChanges(before)
+
+		// Regula 1-D: așteaptă până când cartea nu mai e controlată de altcineva
+		if !board.turnBased {
+			if err := board.waitForCard(ctx, pos, playerID); err != nil {
+				return err
+			}
+		}
+
+		before = board.faces()
+		rule, ok = applyFlip(board, pos, playerID, playerState)
+
+		// Dacă cartea a rămas liberă (ex: a fost eliminată), următorul din coadă poate încerca
+		board.wakeWaiter(pos)
+	} else {
+		rule, ok = applyFlip(board, pos, playerID, playerState)
+	}
+
+	// Ultimul grup: nimeni altcineva nu mai poate juca, deci îl eliminăm
+	// imediat (regula 3-A) ca jocul să se termine fără încă un flip
+	board.removeLastGroup()
+
+	// Incrementează versiunea și notifică listeners doar dacă s-a schimbat ceva vizibil
+	board.commitChanges(before)
+	if !ok {
+		return &RuleError{Rule: rule, Row: row, Col: col}
+	}
+	return nil
+}
+
+// applyFlip întoarce cartea de la pos ca primă sau următoare carte a jucătorului, fără așteptare
+//
+// Specification:
+//
+//	Returns:
+//	  - string: regula aplicată ("1-A" ... "1-D" pentru prima carte,
+//	    "2-A", "2-B", "2-D" sau "2-E" pentru următoarele)
+//	  - bool: true dacă flip-ul a reușit
+//	Preconditions:
+//	  - Apelantul deține board.mu (Lock)
+//	  - pos este o poziție validă pe tablă
+//	Postconditions:
+//	  - Flip-ul este aplicat ca de FlipFirstCard sau FlipSecondCard, după playerState.InProgress
+//	  - FailedFlips crește dacă flip-ul eșuează
+//	  - În modul pe ture, rândul trece la următorul jucător dacă tura s-a încheiat
+//	    fără match (2-A, 2-B, 2-E); după un match jucătorul rămâne la rând
+//	  - Flip-ul este adăugat în journal (vezi Board.logOperation)
+func applyFlip(board *Board, pos Position, playerID string, playerState *PlayerState) (string, bool) {
+	card := &board.Cards[pos.Row][pos.Col]
+
+	var rule string
+	var ok bool
+	if !playerState.InProgress() {
+		switch {
+		case card.Value == "":
+			rule = "1-A"
+		case !card.FaceUp:
+			rule = "1-B"
+		case card.Controller == "":
+			rule = "1-C"
+		default:
+			rule = "1-D"
+		}
+		ok = FlipFirstCard(board, card, pos.Row, pos.Col, playerID, playerState)
+	} else {
+		ok = FlipSecondCard(board, card, pos.Row, pos.Col, playerID, playerState)
+		switch {
+		case card.Value == "":
+			rule = "2-A"
+		case !ok:
+			rule = "2-B"
+		case playerState.Done && !playerState.Matched:
+			rule = "2-E"
+		default:
+			rule = "2-D"
+		}
+	}
+
+	if !ok {
+		playerState.FailedFlips++
+	}
+	if rule == "2-A" || rule == "2-B" || rule == "2-E" {
+		board.endTurn(playerID)
+	}
+	board.logOperation(JournalEntry{Op: OpFlip, Player: playerID, Card: &pos, Rule: rule})
+	return rule, ok
+}
 
 // FlipFirstCard încearcă să întoarcă prima carte pentru un jucător
 // Implementează regulile 1-A, 1-B, 1-C, 1-D din specificația jocului
@@ -22,24 +200,22 @@ import "log"
 //	  - 0 <= row < board.Rows
 //	  - 0 <= col < board.Cols
 //	  - card == &board.Cards[row][col]
-//	  - playerState.HasFirst == false
+//	  - playerState.InProgress() == false (tura anterioară a fost curățată)
 //	Postconditions:
 //	  - Dacă returnează true:
 //	      - card.FaceUp == true
 //	      - card.Controller == playerID
-//	      - playerState.HasFirst == true
-//	      - playerState.FirstCardRow == row
-//	      - playerState.FirstCardCol == col
+//	      - playerState.Held == [(row, col)], deci playerState.InProgress() == true
 //	  - Dacă returnează false, starea nu se modifică
 //	Effects:
 //	  - Poate modifica card (FaceUp, Controller)
-//	  - Poate modifica playerState (HasFirst, FirstCardRow, FirstCardCol)
+//	  - Poate modifica playerState (Held, Done, Matched)
 //	  - Scrie în log
 //	Reguli implementate:
 //	  - 1-A: Spațiu gol (Value == "") → false
 //	  - 1-B: Carte cu fața în jos → true, întoarce cartea
 //	  - 1-C: Carte vizibilă necontrolată → true, preia control
-//	  - 1-D: Carte controlată de altcineva → false
+//	  - 1-D: Carte controlată de altcineva → false (așteptarea se face în FlipCard)
 func FlipFirstCard(board *Board, card *Card, row, col int, playerID string, playerState *PlayerState) bool {
 
 	// Regula 1-A: Nu există carte la această poziție
@@ -48,34 +224,30 @@ func FlipFirstCard(board *Board, card *Card, row, col int, playerID string, play
 		return false
 	}
 
-	// Regula 1-B: Carte cu fața în jos - o întoarcem
+	// Regula 1-D: Carte controlată de altcineva - nu putem face nimic
+	if card.FaceUp && card.Controller != "" {
+		log.Printf("Rule 1-D: Card at (%d, %d) is controlled by %s", row, col, card.Controller)
+		return false
+	}
+
 	if !card.FaceUp {
+		// Regula 1-B: Carte cu fața în jos - o întoarcem
 		log.Printf("Rule 1-B: Turning up card at (%d, %d)", row, col)
 		card.FaceUp = true
-		card.Controller = playerID
-		playerState.HasFirst = true
-		playerState.FirstCardRow = row
-		playerState.FirstCardCol = col
-		return true
-	}
-
-	// Regula 1-C: Carte vizibilă dar necontrolată - preluăm controlul
-	if card.Controller == "" {
+	} else {
+		// Regula 1-C: Carte vizibilă dar necontrolată - preluăm controlul
 		log.Printf("Rule 1-C: Taking control of card at (%d, %d)", row, col)
-		card.Controller = playerID
-		playerState.HasFirst = true
-		playerState.FirstCardRow = row
-		playerState.FirstCardCol = col
-		return true
 	}
-
-	// Regula 1-D: Carte controlată de altcineva - nu putem face nimic
-	log.Printf("Rule 1-D: Card at (%d, %d) is controlled by %s", row, col, card.Controller)
-	return false
+	card.Controller = playerID
+	playerState.Held = []Position{{Row: row, Col: col}}
+	playerState.Done = false
+	playerState.Matched = false
+	return true
 }
 
-// FlipSecondCard încearcă să întoarcă a doua carte pentru un jucător
-// Implementează regulile 2-A, 2-B, 2-C, 2-D, 2-E din specificația jocului
+// FlipSecondCard încearcă să întoarcă următoarea carte (a doua, ..., a K-a) pentru un jucător
+// Implementează regulile 2-A, 2-B, 2-C, 2-D, 2-E din specificația jocului,
+// pentru grupuri de K = board.cardsPerGroup() cărți (K = 2: perechi)
 //
 // Specification:
 //
@@ -94,55 +266,53 @@ func FlipFirstCard(board *Board, card *Card, row, col int, playerID string, play
 //	  - 0 <= row < board.Rows
 //	  - 0 <= col < board.Cols
 //	  - card == &board.Cards[row][col]
-//	  - playerState.HasFirst == true
-//	  - Prima carte există la (playerState.FirstCardRow, playerState.FirstCardCol)
+//	  - playerState.InProgress() == true, cu mai puțin de K cărți în Held
 //	Postconditions:
 //	  - Dacă returnează true:
 //	      - card.FaceUp == true
-//	      - playerState.HasSecond == true
-//	      - playerState.HasFirst == false
-//	      - playerState.SecondCardRow == row
-//	      - playerState.SecondCardCol == col
-//	      - Dacă cărțile se potrivesc:
-//	          - card.Controller == playerID
-//	          - firstCard.Controller == playerID (neschimbat)
-//	          - playerState.Matched == true
-//	      - Dacă cărțile NU se potrivesc:
-//	          - card.Controller == ""
-//	          - firstCard.Controller == ""
-//	          - playerState.Matched == false
+//	      - (row, col) este adăugată la sfârșitul lui playerState.Held
+//	      - Dacă cartea se potrivește cu prima carte ținută (board.matches):
+//	          - card.Controller == playerID; celelalte cărți ținute rămân controlate
+//	          - Dacă Held are acum K cărți: playerState.Done == true și Matched == true
+//	          - Altfel tura continuă (playerState.InProgress() == true)
+//	      - Dacă cartea NU se potrivește:
+//	          - Toate cărțile din Held au Controller == ""
+//	          - playerState.Done == true și playerState.Matched == false
 //	  - Dacă returnează false:
-//	      - firstCard.Controller == ""
-//	      - playerState.HasFirst == false
+//	      - Cărțile ținute înainte au Controller == ""
+//	      - playerState.Held este gol
+//	  - Flip-urile care așteaptă o carte eliberată sunt trezite (board.wakeWaiter)
 //	Effects:
 //	  - Poate modifica card (FaceUp, Controller)
-//	  - Poate modifica firstCard (Controller)
+//	  - Poate modifica cărțile ținute (Controller)
 //	  - Modifică întotdeauna playerState
 //	  - Scrie în log
 //	Reguli implementate:
-//	  - 2-A: Spațiu gol → false, renunță la prima carte
-//	  - 2-B: Carte controlată → false, renunță la prima carte
+//	  - 2-A: Spațiu gol → false, renunță la cărțile ținute
+//	  - 2-B: Carte controlată → false, renunță la cărțile ținute
 //	  - 2-C: Carte cu fața în jos → o întoarce
-//	  - 2-D: Cărți identice → true, MATCH
-//	  - 2-E: Cărți diferite → true, NO MATCH
+//	  - 2-D: Carte care se potrivește (identică, cu matcher-ul implicit) → true, MATCH
+//	    (grupul e complet la a K-a carte)
+//	  - 2-E: Carte care nu se potrivește → true, NO MATCH
 func FlipSecondCard(board *Board, card *Card, row, col int, playerID string, playerState *PlayerState) bool {
 
-	// Obține prima carte
-	firstCard := &board.Cards[playerState.FirstCardRow][playerState.FirstCardCol]
+	// Obține prima carte ținută; toate cărțile ținute se potrivesc cu ea
+	first := playerState.Held[0]
+	firstCard := &board.Cards[first.Row][first.Col]
 
-	// Regula 2-A: Nu există carte - renunță la prima carte
+	// Regula 2-A: Nu există carte - renunță la cărțile ținute
 	if card.Value == "" {
-		log.Printf("Rule 2-A: No card at (%d, %d), relinquishing first card", row, col)
-		firstCard.Controller = ""
-		playerState.HasFirst = false
+		log.Printf("Rule 2-A: No card at (%d, %d), relinquishing held cards", row, col)
+		relinquishHeld(board, playerState)
+		playerState.Held = nil
 		return false
 	}
 
-	// Regula 2-B: Carte controlată - renunță la prima carte
+	// Regula 2-B: Carte controlată - renunță la cărțile ținute
 	if card.FaceUp && card.Controller != "" {
-		log.Printf("Rule 2-B: Card at (%d, %d) is controlled, relinquishing first card", row, col)
-		firstCard.Controller = ""
-		playerState.HasFirst = false
+		log.Printf("Rule 2-B: Card at (%d, %d) is controlled, relinquishing held cards", row, col)
+		relinquishHeld(board, playerState)
+		playerState.Held = nil
 		return false
 	}
 
@@ -152,29 +322,47 @@ func FlipSecondCard(board *Board, card *Card, row, col int, playerID string, pla
 		card.FaceUp = true
 	}
 
-	// Salvăm poziția celei de-a doua cărți
-	playerState.SecondCardRow = row
-	playerState.SecondCardCol = col
-	playerState.HasSecond = true
-	playerState.HasFirst = false
+	// Salvăm poziția cărții
+	playerState.Held = append(playerState.Held, Position{Row: row, Col: col})
 
-	// Verificăm dacă cărțile se potrivesc
-	if firstCard.Value == card.Value {
-		// Regula 2-D: MATCH - ambele cărți rămân controlate
-		log.Printf("Rule 2-D: Match! %s == %s", firstCard.Value, card.Value)
+	// Verificăm dacă cartea se potrivește cu prima, după matcher-ul tablei
+	if board.matches(firstCard.Value, card.Value) {
+		// Regula 2-D: MATCH - toate cărțile rămân controlate
 		card.Controller = playerID
+		if len(playerState.Held) < board.cardsPerGroup() {
+			log.Printf("Rule 2-D: Match! %s ~ %s (%d of %d)", firstCard.Value, card.Value, len(playerState.Held), board.cardsPerGroup())
+			return true
+		}
+		log.Printf("Rule 2-D: Match! %s ~ %s", firstCard.Value, card.Value)
+		playerState.Done = true
 		playerState.Matched = true
 	} else {
-		// Regula 2-E: NO MATCH - ambele cărți devin necontrolate dar vizibile
-		log.Printf("Rule 2-E: No match. %s != %s", firstCard.Value, card.Value)
-		firstCard.Controller = ""
-		card.Controller = ""
+		// Regula 2-E: NO MATCH - toate cărțile devin necontrolate dar vizibile
+		log.Printf("Rule 2-E: No match. %s !~ %s", firstCard.Value, card.Value)
+		relinquishHeld(board, playerState)
+		playerState.Done = true
 		playerState.Matched = false
 	}
 
 	return true
 }
 
+// relinquishHeld eliberează cărțile ținute de jucător, fără să le întoarcă
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține board.mu (Lock)
+//	Postconditions:
+//	  - Cărțile din playerState.Held au Controller == ""; Held nu se modifică
+//	  - Flip-urile care așteaptă aceste cărți sunt trezite (board.wakeWaiter)
+func relinquishHeld(board *Board, playerState *PlayerState) {
+	for _, pos := range playerState.Held {
+		board.Cards[pos.Row][pos.Col].Controller = ""
+		board.wakeWaiter(pos)
+	}
+}
+
 // CleanupPreviousPlay curăță tabla după tura anterioară a jucătorului
 // Implementează regulile 3-A și 3-B din specificația jocului
 //
@@ -187,19 +375,18 @@ func FlipSecondCard(board *Board, card *Card, row, col int, playerID string, pla
 //	Preconditions:
 //	  - board != nil
 //	  - playerState != nil
-//	  - Dacă playerState.HasFirst == true:
-//	      - Prima carte există la (FirstCardRow, FirstCardCol)
-//	  - Dacă playerState.HasSecond == true:
-//	      - Prima și a doua carte există la pozițiile respective
+//	  - Pozițiile din playerState.Held sunt pe tablă
 //	Postconditions:
-//	  - playerState.HasFirst == false
-//	  - playerState.HasSecond == false
-//	  - playerState.Matched == false
+//	  - playerState.Held este gol, Done == false, Matched == false
 //	  - Dacă tura anterioară a avut match (Matched == true):
 //	      - Cărțile potrivite controlate de playerID sunt eliminate
 //	        (Value="", FaceUp=false, Controller="")
-//	  - Dacă tura anterioară NU a avut match (Matched == false):
+//	      - playerState.Pairs crește cu 1 dacă toate cărțile erau controlate de playerID
+//	  - Altfel:
+//	      - Dacă tura era încă în curs, cărțile ținute sunt eliberate
 //	      - Cărțile necontrolate (Controller=="") sunt întoarse cu fața în jos
+//	  - Flip-urile care așteaptă o carte eliminată sau eliberată sunt trezite (board.wakeWaiter)
+//	  - Dacă jucătorul avea cărți întoarse, cleanup-ul este adăugat în journal
 //	Effects:
 //	  - Poate modifica cărțile din board.Cards
 //	  - Modifică întotdeauna playerState
@@ -209,58 +396,90 @@ func FlipSecondCard(board *Board, card *Card, row, col int, playerID string, pla
 //	  - 3-B: Cărți nepotrivite → întoarce cu fața în jos (doar dacă Controller == "")
 func CleanupPreviousPlay(board *Board, playerState *PlayerState, playerID string) {
 
-	log.Printf("Cleaning up previous play for player %s (HasFirst: %v, HasSecond: %v, Matched: %v)",
-		playerID, playerState.HasFirst, playerState.HasSecond, playerState.Matched)
+	log.Printf("Cleaning up previous play for player %s (Held: %v, Done: %v, Matched: %v)",
+		playerID, playerState.Held, playerState.Done, playerState.Matched)
 
-	// Dacă jucătorul avea doar prima carte întorsă
-	if playerState.HasFirst {
-		card1 := &board.Cards[playerState.FirstCardRow][playerState.FirstCardCol]
-
-		// Regula 3-B: Întoarce cartea cu fața în jos dacă nu e controlată
-		if card1.Value != "" && card1.FaceUp && card1.Controller == "" {
-			log.Printf("Turning down card at (%d, %d)", playerState.FirstCardRow, playerState.FirstCardCol)
-			card1.FaceUp = false
-		}
+	if rule := cleanupRule(playerState); rule != "" {
+		board.logOperation(JournalEntry{Op: OpCleanup, Player: playerID, Rule: rule})
 	}
 
-	// Dacă jucătorul avea două cărți întoarse
-	if playerState.HasSecond {
-		card1 := &board.Cards[playerState.FirstCardRow][playerState.FirstCardCol]
-		card2 := &board.Cards[playerState.SecondCardRow][playerState.SecondCardCol]
-
-		if playerState.Matched {
-			// Regula 3-A: Elimină cărțile potrivite
-			if card1.Controller == playerID {
-				log.Printf("Removing matched card at (%d, %d)", playerState.FirstCardRow, playerState.FirstCardCol)
-				card1.Value = ""
-				card1.FaceUp = false
-				card1.Controller = ""
-			}
-			if card2.Controller == playerID {
-				log.Printf("Removing matched card at (%d, %d)", playerState.SecondCardRow, playerState.SecondCardCol)
-				card2.Value = ""
-				card2.FaceUp = false
-				card2.Controller = ""
+	if playerState.Matched {
+		// Regula 3-A: Elimină cărțile potrivite și numără grupul
+		all := true
+		for _, pos := range playerState.Held {
+			if board.Cards[pos.Row][pos.Col].Controller != playerID {
+				all = false
 			}
-		} else {
-			// Regula 3-B: Întoarce cărțile nepotrivite cu fața în jos
-			if card1.Value != "" && card1.FaceUp && card1.Controller == "" {
-				log.Printf("Turning down card at (%d, %d)", playerState.FirstCardRow, playerState.FirstCardCol)
-				card1.FaceUp = false
+		}
+		if all {
+			playerState.Pairs++
+		}
+		for _, pos := range playerState.Held {
+			card := &board.Cards[pos.Row][pos.Col]
+			if card.Controller == playerID {
+				log.Printf("Removing matched card at (%d, %d)", pos.Row, pos.Col)
+				card.Value = ""
+				card.FaceUp = false
+				card.Controller = ""
+				board.wakeWaiter(pos)
 			}
-			if card2.Value != "" && card2.FaceUp && card2.Controller == "" {
-				log.Printf("Turning down card at (%d, %d)", playerState.SecondCardRow, playerState.SecondCardCol)
-				card2.FaceUp = false
+		}
+	} else {
+		// O tură neterminată (ex: jucătorul a plecat) își eliberează întâi cărțile
+		if !playerState.Done {
+			relinquishHeld(board, playerState)
+		}
+
+		// Regula 3-B: Întoarce cărțile nepotrivite cu fața în jos
+		for _, pos := range playerState.Held {
+			card := &board.Cards[pos.Row][pos.Col]
+			if card.Value != "" && card.FaceUp && card.Controller == "" {
+				log.Printf("Turning down card at (%d, %d)", pos.Row, pos.Col)
+				card.FaceUp = false
 			}
 		}
 	}
 
 	// Resetează starea jucătorului
-	playerState.HasFirst = false
-	playerState.HasSecond = false
+	playerState.Held = nil
+	playerState.Done = false
 	playerState.Matched = false
 }
 
+// Replace execută ReplaceCards pentru un jucător, cu lock-urile necesare
+//
+// Specification:
+//
+//	Parameters:
+//	  - board: pointer către Board (nu trebuie nil)
+//	  - playerID, fromCard, toCard: ca la ReplaceCards
+//	Returns:
+//	  - bool: true dacă cel puțin o carte a fost înlocuită
+//	  - error: *SpectatorError dacă playerID este spectator (nimic nu se schimbă)
+//	Postconditions:
+//	  - Dacă vreo carte și-a schimbat valoarea, board.version este incrementat
+//	    și listeners sunt notificați
+//	  - LastAction al jucătorului este actualizat
+//	  - Dacă vreo carte a fost înlocuită, operația este adăugată în journal
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește board.mu)
+func Replace(board *Board, playerID, fromCard, toCard string) (bool, error) {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	if err := board.checkPlayer(playerID); err != nil {
+		return false, err
+	}
+	board.GetPlayerState(playerID).LastAction = board.now()
+	before := board.faces()
+	replaced := ReplaceCards(board, playerID, fromCard, toCard)
+	if replaced {
+		board.logOperation(JournalEntry{Op: OpReplace, Player: playerID, From: fromCard, To: toCard})
+	}
+	board.commitChanges(before)
+	return replaced, nil
+}
+
 // ReplaceCards înlocuiește toate cărțile controlate de jucător cu o valoare nouă
 //
 // Specification:
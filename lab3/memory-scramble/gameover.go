@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// ResetMode descrie ce se întâmplă cu tabla după ce jocul s-a terminat
+type ResetMode string
+
+const (
+	ResetNone    ResetMode = "none"    // Tabla rămâne goală
+	ResetReload  ResetMode = "reload"  // Cărțile revin la pozițiile inițiale
+	ResetShuffle ResetMode = "shuffle" // Cărțile inițiale sunt amestecate
+)
+
+// ParseResetMode convertește numele unui mod de reset (ex: din flag-ul -reset)
+//
+// Specification:
+//
+//	Returns:
+//	  - ResetMode: modul corespunzător lui name
+//	  - error: non-nil dacă name nu este "none", "reload" sau "shuffle"
+func ParseResetMode(name string) (ResetMode, error) {
+	switch mode := ResetMode(name); mode {
+	case ResetNone, ResetReload, ResetShuffle:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown reset mode %q (want none, reload or shuffle)", name)
+}
+
+// SetAutoReset configurează reset-ul automat de după terminarea jocului
+//
+// Specification:
+//
+//	Parameters:
+//	  - mode: ResetNone dezactivează reset-ul automat
+//	  - delay: cât timp rămâne vizibilă starea finală înainte de reset (>= 0)
+//	Postconditions:
+//	  - Jocurile terminate de acum înainte sunt resetate după delay conform mode
+//	  - Dacă jocul e deja terminat (ex: restaurat dintr-un snapshot) și nu există
+//	    un reset programat, reset-ul este programat acum
+//	  - Un reset deja programat nu este afectat
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) SetAutoReset(mode ResetMode, delay time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetMode = mode
+	b.resetDelay = delay
+	if b.gameOver && b.resetTimer == nil {
+		b.scheduleReset()
+	}
+}
+
+// GameOver raportează dacă jocul s-a terminat și cine l-a câștigat
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă toate cărțile au fost eliminate și tabla nu a fost resetată
+//	  - []string: jucătorii cu cele mai multe perechi, sortați după ID
+//	    (mai mulți la egalitate; gol dacă nimeni nu a colectat nicio pereche)
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu.RLock)
+func (b *Board) GameOver() (bool, []string) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.gameOver, append([]string(nil), b.winners...)
+}
+
+// Reset readuce pe tablă cărțile inițiale și începe un joc nou
+//
+// Specification:
+//
+//	Parameters:
+//	  - shuffle: true pentru a amesteca pozițiile cărților inițiale
+//	Postconditions:
+//	  - Toate cărțile au valorile inițiale (eventual amestecate), cu fața în jos, necontrolate
+//	  - Toți jucătorii pierd cărțile ținute; Pairs și FailedFlips sunt puse pe 0
+//	  - Jocul nu mai este terminat; un reset programat este anulat
+//	  - version este incrementat și listeners sunt notificați
+//	  - Flip-urile care așteaptă o carte sunt trezite
+//	  - Reset-ul este adăugat în journal, cu valorile noi ale cărților
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu, apoi playerStatesMu)
+func (b *Board) Reset(shuffle bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reset(shuffle)
+}
+
+// reset implementează Reset
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+func (b *Board) reset(shuffle bool) {
+	values := make([]string, 0, b.Rows*b.Cols)
+	for _, row := range b.layout {
+		values = append(values, row...)
+	}
+	if shuffle {
+		rand.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	}
+
+	before := b.faces()
+	b.resetCards(values)
+
+	log.Printf("Board reset (shuffle: %v)", shuffle)
+	if !b.commitChanges(before) {
+		// Tabla arată la fel (ex: reset înainte de orice flip), dar jocul e altul
+		b.publishVersion()
+	}
+
+	// Journal-ul reține valorile noi, ca replay-ul să nu depindă de amestecare
+	layout := make([][]string, b.Rows)
+	for i := range layout {
+		layout[i] = values[i*b.Cols : (i+1)*b.Cols]
+	}
+	b.logOperation(JournalEntry{Op: OpReset, Layout: layout})
+	b.flushJournal()
+}
+
+// resetCards pune pe tablă cărțile cu valorile date și începe un joc nou
+//
+// Specification:
+//
+//	Parameters:
+//	  - values: valorile cărților, rând cu rând (len(values) == Rows*Cols)
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Cărțile au valorile date, cu fața în jos, necontrolate
+//	  - Jucătorii pierd cărțile ținute; Pairs și FailedFlips sunt puse pe 0
+//	  - Jocul nu mai este terminat; un reset programat este anulat
+//	  - În modul pe ture, primul jucător intrat este din nou la rând
+//	  - Flip-urile care așteaptă o carte sunt trezite
+//	  - version nu se schimbă (apelantul face commitChanges)
+func (b *Board) resetCards(values []string) {
+	if b.resetTimer != nil {
+		b.resetTimer.Stop()
+		b.resetTimer = nil
+	}
+
+	for i := 0; i < b.Rows; i++ {
+		for j := 0; j < b.Cols; j++ {
+			b.Cards[i][j] = NewCard(values[i*b.Cols+j])
+		}
+	}
+
+	b.playerStatesMu.Lock()
+	for _, state := range b.playerStates {
+		lastAction := state.LastAction
+		*state = *NewPlayerState()
+		state.LastAction = lastAction
+	}
+	b.playerStatesMu.Unlock()
+
+	b.gameOver = false
+	b.winners = nil
+	b.turn = 0
+	b.turnMoved = len(b.turnOrder) > 0
+	b.turnStarted = b.now()
+	for pos := range b.waiters {
+		b.wakeWaiter(pos)
+	}
+}
+
+// checkGameOver marchează jocul ca terminat dacă toate cărțile au fost eliminate
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Dacă jocul tocmai s-a terminat: gameOver == true, winners conține jucătorii
+//	    cu cele mai multe perechi, iar dacă resetMode != ResetNone este programat
+//	    un reset după resetDelay
+//	  - Altfel starea nu se modifică
+//	Effects:
+//	  - Folosește lock pe playerStatesMu
+//	  - Scrie în log
+func (b *Board) checkGameOver() {
+	if b.gameOver || b.remainingCards() > 0 {
+		return
+	}
+
+	b.gameOver = true
+	b.winners = nil
+	best := 0
+	b.playerStatesMu.Lock()
+	for playerID, state := range b.playerStates {
+		switch {
+		case state.Pairs > best:
+			best = state.Pairs
+			b.winners = []string{playerID}
+		case state.Pairs == best && best > 0:
+			b.winners = append(b.winners, playerID)
+		}
+	}
+	b.playerStatesMu.Unlock()
+	sort.Strings(b.winners)
+	log.Printf("Game over, winners: %v", b.winners)
+
+	b.scheduleReset()
+}
+
+// scheduleReset programează reset-ul automat, dacă e activat
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Dacă resetMode este ResetReload sau ResetShuffle, Reset este apelat după
+//	    resetDelay, cu excepția cazului în care StopAutoReset este apelat între timp
+func (b *Board) scheduleReset() {
+	if b.resetMode == ResetReload || b.resetMode == ResetShuffle {
+		shuffle := b.resetMode == ResetShuffle
+		b.resetTimer = time.AfterFunc(b.resetDelay, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			// Timer-ul poate porni chiar când StopAutoReset îl oprește
+			if b.resetMode != ResetNone {
+				b.reset(shuffle)
+			}
+		})
+	}
+}
+
+// StopAutoReset oprește definitiv reset-ul automat al tablei (ex: pentru un joc șters)
+//
+// Specification:
+//
+//	Postconditions:
+//	  - Reset-ul programat, dacă există, nu mai are loc
+//	  - resetMode este ResetNone, deci nu se mai programează alte reset-uri
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) StopAutoReset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetMode = ResetNone
+	if b.resetTimer != nil {
+		b.resetTimer.Stop()
+		b.resetTimer = nil
+	}
+}
+
+// FormatGameOver formatează linia care anunță sfârșitul jocului
+//
+// Specification:
+//
+//	Returns:
+//	  - string: "over" urmat de câștigători separați prin spațiu și de "\n"
+func FormatGameOver(winners []string) string {
+	line := "over"
+	for _, winner := range winners {
+		line += " " + winner
+	}
+	return line + "\n"
+}
+
+// remainingCards numără cărțile care nu au fost eliminate
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu
+func (b *Board) remainingCards() int {
+	count := 0
+	for i := 0; i < b.Rows; i++ {
+		for j := 0; j < b.Cols; j++ {
+			if b.Cards[i][j].Value != "" {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// removeLastGroup elimină ultimul grup de pe tablă dacă un jucător l-a potrivit deja
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Dacă singurele cărți rămase sunt grupul potrivit al unui jucător, acesta
+//	    face cleanup (regula 3-A): grupul este eliminat și numărat
+//	  - Altfel starea nu se modifică
+//	Effects:
+//	  - Folosește lock pe playerStatesMu
+func (b *Board) removeLastGroup() {
+	if b.remainingCards() != b.cardsPerGroup() {
+		return
+	}
+
+	b.playerStatesMu.Lock()
+	var ownerID string
+	var owner *PlayerState
+	for playerID, state := range b.playerStates {
+		if !state.Matched {
+			continue
+		}
+		held := true
+		for _, pos := range state.Held {
+			if b.Cards[pos.Row][pos.Col].Controller != playerID {
+				held = false
+			}
+		}
+		if held {
+			ownerID, owner = playerID, state
+			break
+		}
+	}
+	b.playerStatesMu.Unlock()
+
+	if owner != nil {
+		CleanupPreviousPlay(b, owner, ownerID)
+	}
+}
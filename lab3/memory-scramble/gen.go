@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Seturile de simboluri din care GenerateBoard alege valorile cărților
+const (
+	SymbolsLetters = "letters" // A, B, ..., Z, AA, AB, ...
+	SymbolsNumbers = "numbers" // 1, 2, 3, ...
+	SymbolsEmoji   = "emoji"   // Emoji din genEmoji, în ordine aleatoare
+	SymbolsWords   = "words"   // Cuvintele din GenOptions.Words, în ordine aleatoare
+)
+
+// genHoleMark este marcajul scris pentru celulele goale (#hole .)
+const genHoleMark = "."
+
+// genEmoji sunt emoji-urile disponibile pentru SymbolsEmoji
+var genEmoji = []string{
+	"🐶", "🐱", "🐭", "🐹", "🐰", "🦊", "🐻", "🐼", "🐨", "🐯",
+	"🦁", "🐮", "🐷", "🐸", "🐵", "🐔", "🐧", "🐦", "🦆", "🦉",
+	"🐴", "🦄", "🐝", "🦋", "🐌", "🐞", "🐢", "🐍", "🐙", "🦀",
+	"🐠", "🐬", "🐳", "🦈", "🐊", "🦓", "🦒", "🐘", "🦔", "🦩",
+	"🍎", "🍐", "🍊", "🍋", "🍌", "🍉", "🍇", "🍓", "🍒", "🍑",
+	"🥝", "🍍", "🥥", "🥕", "🌽", "🍄", "🌵", "🌻", "🌈", "⭐",
+}
+
+// GenOptions descrie tabla generată de GenerateBoard
+type GenOptions struct {
+	Rows      int      // Numărul de rânduri (1..maxBoardDimension)
+	Cols      int      // Numărul de coloane (1..maxBoardDimension)
+	GroupSize int      // Câte cărți egale formează un grup (2..maxGroupSize, 0 = 2)
+	Symbols   string   // Setul de simboluri: SymbolsLetters (implicit), SymbolsNumbers, SymbolsEmoji sau SymbolsWords
+	Words     []string // Cuvintele pentru SymbolsWords (valori valide, distincte)
+	Holes     int      // Câte celule sunt deja goale
+	Seed      int64    // Seed-ul generatorului: același seed și aceleași opțiuni dau aceeași tablă
+}
+
+// GenerateBoard generează un fișier de tablă valid
+//
+// Specification:
+//
+//	Parameters:
+//	  - opts: dimensiunile, mărimea grupurilor, simbolurile, golurile și seed-ul
+//	Returns:
+//	  - string: fișierul de tablă, în formatul citit de ParseBoard; începe cu
+//	    "#group K" dacă K != 2 și cu "#hole ." dacă opts.Holes > 0
+//	  - error: non-nil dacă opțiunile nu sunt valide, dacă (Rows*Cols - Holes) nu
+//	    se împarte în grupuri de GroupSize, dacă setul de simboluri nu are destule
+//	    valori sau dacă ParseBoard respinge tabla generată
+//	Postconditions:
+//	  - Fiecare valoare apare de exact GroupSize ori, iar golurile sunt așezate
+//	    aleator printre cărți
+//	  - Rezultatul depinde doar de opts (inclusiv Seed)
+func GenerateBoard(opts GenOptions) (string, error) {
+	if opts.GroupSize == 0 {
+		opts.GroupSize = 2
+	}
+	if opts.Rows < 1 || opts.Rows > maxBoardDimension || opts.Cols < 1 || opts.Cols > maxBoardDimension {
+		return "", fmt.Errorf("board dimensions must be between 1 and %d", maxBoardDimension)
+	}
+	if opts.GroupSize < 2 || opts.GroupSize > maxGroupSize {
+		return "", fmt.Errorf("group size must be between 2 and %d", maxGroupSize)
+	}
+	cells := opts.Rows * opts.Cols
+	if opts.Holes < 0 || opts.Holes >= cells {
+		return "", fmt.Errorf("holes must be between 0 and %d", cells-1)
+	}
+	cards := cells - opts.Holes
+	if cards%opts.GroupSize != 0 {
+		return "", fmt.Errorf("%d cards do not split into groups of %d", cards, opts.GroupSize)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	symbols, err := genSymbols(opts, cards/opts.GroupSize, rng)
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]string, 0, cells)
+	for _, symbol := range symbols {
+		for i := 0; i < opts.GroupSize; i++ {
+			values = append(values, symbol)
+		}
+	}
+	for i := 0; i < opts.Holes; i++ {
+		values = append(values, genHoleMark)
+	}
+	rng.Shuffle(len(values), func(i, j int) {
+		values[i], values[j] = values[j], values[i]
+	})
+
+	var text strings.Builder
+	if opts.GroupSize != 2 {
+		fmt.Fprintf(&text, "#group %d\n", opts.GroupSize)
+	}
+	if opts.Holes > 0 {
+		fmt.Fprintf(&text, "#hole %s\n", genHoleMark)
+	}
+	fmt.Fprintf(&text, "%dx%d\n", opts.Rows, opts.Cols)
+	for _, value := range values {
+		text.WriteString(value)
+		text.WriteByte('\n')
+	}
+
+	// Tabla trece prin același parser ca tablele încărcate de server
+	if _, err := ParseBoard(strings.NewReader(text.String()), ParseOptions{GroupSize: opts.GroupSize}); err != nil {
+		return "", fmt.Errorf("generated board is invalid: %w", err)
+	}
+	return text.String(), nil
+}
+
+// genSymbols alege kinds valori distincte din setul opts.Symbols
+//
+// Specification:
+//
+//	Returns:
+//	  - []string: kinds valori valide (IsValidCardValue), distincte și diferite
+//	    de genHoleMark
+//	  - error: non-nil dacă setul nu există sau nu are kinds valori
+func genSymbols(opts GenOptions, kinds int, rng *rand.Rand) ([]string, error) {
+	var pool []string
+	switch opts.Symbols {
+	case SymbolsLetters, "":
+		symbols := make([]string, kinds)
+		for i := range symbols {
+			symbols[i] = letterName(i)
+		}
+		return symbols, nil
+	case SymbolsNumbers:
+		symbols := make([]string, kinds)
+		for i := range symbols {
+			symbols[i] = strconv.Itoa(i + 1)
+		}
+		return symbols, nil
+	case SymbolsEmoji:
+		pool = append(pool, genEmoji...)
+	case SymbolsWords:
+		seen := make(map[string]bool)
+		for _, word := range opts.Words {
+			if !IsValidCardValue(word) || word == genHoleMark {
+				return nil, fmt.Errorf("invalid word %q", word)
+			}
+			if seen[word] {
+				return nil, fmt.Errorf("duplicate word %q", word)
+			}
+			seen[word] = true
+			pool = append(pool, word)
+		}
+	default:
+		return nil, fmt.Errorf("unknown symbol set %q (letters, numbers, emoji or words)", opts.Symbols)
+	}
+
+	if len(pool) < kinds {
+		return nil, fmt.Errorf("symbol set %s has %d values, the board needs %d", opts.Symbols, len(pool), kinds)
+	}
+	rng.Shuffle(len(pool), func(i, j int) {
+		pool[i], pool[j] = pool[j], pool[i]
+	})
+	return pool[:kinds], nil
+}
+
+// letterName returnează numele literei cu indexul i: A..Z, apoi AA, AB, ... ca în coloanele unui spreadsheet
+func letterName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// readWords citește cuvintele dintr-un fișier, câte unul pe linie
+//
+// Specification:
+//
+//	Returns:
+//	  - []string: liniile nevide, fără spațiile de la capete
+//	  - error: non-nil dacă fișierul nu poate fi citit sau o linie nu e o carte validă
+func readWords(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" {
+			continue
+		}
+		if !IsValidCardValue(word) {
+			return nil, fmt.Errorf("%s:%d: invalid word %q", filename, line, word)
+		}
+		words = append(words, word)
+	}
+	return words, scanner.Err()
+}
+
+// runGen este comanda "gen": generează un fișier de tablă
+//
+// Specification:
+//
+//	Parameters:
+//	  - args: argumentele de după "gen" (flag-urile de mai jos)
+//	Returns:
+//	  - error: non-nil dacă opțiunile nu sunt valide sau fișierul nu poate fi scris
+//	Postconditions:
+//	  - Tabla este scrisă în -o sau la stdout; seed-ul folosit este afișat pe
+//	    stderr, ca tabla să poată fi generată din nou
+func runGen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	rows := flags.Int("rows", 5, "numărul de rânduri")
+	cols := flags.Int("cols", 5, "numărul de coloane")
+	groupSize := flags.Int("group", 2, "câte cărți egale formează un grup (#group)")
+	symbols := flags.String("symbols", SymbolsLetters, "simbolurile cărților: letters, numbers, emoji sau words")
+	wordsFile := flags.String("words", "", "fișierul cu cuvinte, câte unul pe linie (pentru -symbols words)")
+	holes := flags.Int("holes", 0, "câte celule sunt deja goale (#hole)")
+	seed := flags.Int64("seed", 0, "seed-ul generatorului (0 = aleator)")
+	output := flags.String("o", "", "fișierul în care se scrie tabla (\"\" = stdout)")
+	flags.Parse(args)
+
+	opts := GenOptions{
+		Rows:      *rows,
+		Cols:      *cols,
+		GroupSize: *groupSize,
+		Symbols:   *symbols,
+		Holes:     *holes,
+		Seed:      *seed,
+	}
+	if *symbols == SymbolsWords {
+		if *wordsFile == "" {
+			return errors.New("gen: -words is required with -symbols words")
+		}
+		words, err := readWords(*wordsFile)
+		if err != nil {
+			return err
+		}
+		opts.Words = words
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+
+	text, err := GenerateBoard(opts)
+	if err != nil {
+		return fmt.Errorf("gen: %w", err)
+	}
+	log.Printf("Generated a %dx%d board with seed %d", opts.Rows, opts.Cols, opts.Seed)
+	if *output == "" {
+		_, err := os.Stdout.WriteString(text)
+		return err
+	}
+	return os.WriteFile(*output, []byte(text), 0o644)
+}
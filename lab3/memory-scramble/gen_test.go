@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test that generated boards parse, keep the group size and holes, and repeat for the same seed
+func TestGenerateBoard(t *testing.T) {
+	opts := GenOptions{Rows: 4, Cols: 5, GroupSize: 3, Symbols: SymbolsEmoji, Holes: 2, Seed: 42}
+	text, err := GenerateBoard(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := GenerateBoard(opts); again != text {
+		t.Error("Expected the same seed to generate the same board")
+	}
+	opts.Seed = 43
+	if other, _ := GenerateBoard(opts); other == text {
+		t.Error("Expected another seed to generate another board")
+	}
+
+	if !strings.HasPrefix(text, "#group 3\n#hole .\n4x5\n") {
+		t.Errorf("Unexpected header in %q", text)
+	}
+	counts := make(map[string]int)
+	for _, value := range strings.Split(strings.TrimSuffix(text, "\n"), "\n")[3:] {
+		counts[value]++
+	}
+	if counts["."] != 2 || len(counts) != 7 {
+		t.Errorf("Expected 2 holes and 6 groups, got %v", counts)
+	}
+	for value, count := range counts {
+		if value != "." && count != 3 {
+			t.Errorf("Expected %s to appear 3 times, got %d", value, count)
+		}
+	}
+}
+
+// Test the letter and number sets and the options GenerateBoard refuses
+func TestGenerateBoardSymbols(t *testing.T) {
+	if got := []string{letterName(0), letterName(25), letterName(26), letterName(27 * 26)}; strings.Join(got, " ") != "A Z AA AAA" {
+		t.Errorf("Unexpected letter names %v", got)
+	}
+	text, err := GenerateBoard(GenOptions{Rows: 1, Cols: 4, Symbols: SymbolsNumbers, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(text, "1\n") != 2 || strings.Count(text, "2\n") != 2 || !strings.HasPrefix(text, "1x4\n") {
+		t.Errorf("Unexpected numbers board %q", text)
+	}
+	if _, err := GenerateBoard(GenOptions{Rows: 1, Cols: 4, Symbols: SymbolsWords, Words: []string{"cat", "dog"}}); err != nil {
+		t.Errorf("Unexpected error for a words board: %v", err)
+	}
+
+	for name, opts := range map[string]GenOptions{
+		"odd cards":      {Rows: 3, Cols: 3},
+		"only holes":     {Rows: 1, Cols: 2, Holes: 2},
+		"too few emoji":  {Rows: 20, Cols: 20, Symbols: SymbolsEmoji},
+		"too few words":  {Rows: 2, Cols: 2, Symbols: SymbolsWords, Words: []string{"cat"}},
+		"duplicate word": {Rows: 2, Cols: 2, Symbols: SymbolsWords, Words: []string{"cat", "cat"}},
+		"hole word":      {Rows: 2, Cols: 2, Symbols: SymbolsWords, Words: []string{"cat", "."}},
+		"unknown set":    {Rows: 2, Cols: 2, Symbols: "colors"},
+		"group size":     {Rows: 2, Cols: 5, GroupSize: 10},
+	} {
+		if _, err := GenerateBoard(opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
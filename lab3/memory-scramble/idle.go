@@ -0,0 +1,141 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// SetIdleTimeout configurează cât timp poate ține un jucător cărți fără să joace
+//
+// Specification:
+//
+//	Parameters:
+//	  - timeout: durata maximă de inactivitate, sau 0 pentru a dezactiva limita
+//	Postconditions:
+//	  - ReleaseIdlePlayers eliberează de acum înainte jucătorii inactivi de cel puțin timeout
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) SetIdleTimeout(timeout time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.idleTimeout = timeout
+}
+
+// SetClock înlocuiește ceasul tablei (ex: cu un ceas controlat de teste)
+//
+// Specification:
+//
+//	Parameters:
+//	  - now: funcția care dă momentul curent, sau nil pentru time.Now
+//	Postconditions:
+//	  - LastAction al jucătorilor și limita de inactivitate folosesc now
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) SetClock(now func() time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clock = now
+}
+
+// now returnează momentul curent după ceasul tablei
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu
+func (b *Board) now() time.Time {
+	if b.clock == nil {
+		return time.Now()
+	}
+	return b.clock()
+}
+
+// ReleaseIdlePlayers face cleanup pentru jucătorii care țin cărți și nu au mai jucat
+// de cel puțin idleTimeout
+//
+// Specification:
+//
+//	Returns:
+//	  - []string: jucătorii eliberați, sortați după ID (nil dacă limita e dezactivată)
+//	Postconditions:
+//	  - Pentru fiecare jucător cu cărți întoarse și LastAction mai vechi de idleTimeout,
+//	    cărțile lui sunt eliberate și se aplică cleanup-ul (vezi CleanupPreviousPlay):
+//	    un grup potrivit este eliminat și numărat, celelalte cărți sunt întoarse
+//	  - În modul pe ture, jucătorul la rând care nu a jucat de idleTimeout de când
+//	    i-a venit rândul îl pierde (dacă mai sunt alți jucători); pierderea rândului
+//	    este adăugată în journal
+//	  - Flip-urile care așteaptă cărțile eliberate sunt trezite
+//	  - Dacă tabla s-a schimbat vizibil, version este incrementat și listeners sunt notificați
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu, apoi playerStatesMu)
+func (b *Board) ReleaseIdlePlayers() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.idleTimeout <= 0 {
+		return nil
+	}
+
+	now := b.now()
+	active := b.activePlayer()
+	var idle []string
+	b.playerStatesMu.Lock()
+	for playerID, state := range b.playerStates {
+		last := state.LastAction
+		waiting := playerID == active && len(b.turnOrder) > 1
+		if waiting && b.turnStarted.After(last) {
+			last = b.turnStarted
+		}
+		if (len(state.Held) > 0 || waiting) && now.Sub(last) >= b.idleTimeout {
+			idle = append(idle, playerID)
+		}
+	}
+	b.playerStatesMu.Unlock()
+	sort.Strings(idle)
+
+	before := b.faces()
+	for _, playerID := range idle {
+		log.Printf("Player %s was idle for %v, releasing their cards", playerID, b.idleTimeout)
+		CleanupPreviousPlay(b, b.GetPlayerState(playerID), playerID)
+		if playerID == active && len(b.turnOrder) > 1 {
+			b.endTurn(playerID)
+			b.logOperation(JournalEntry{Op: OpTimeout, Player: playerID})
+		}
+	}
+	b.commitChanges(before)
+	return idle
+}
+
+// ReleaseIdlePlayers aplică Board.ReleaseIdlePlayers pe toate jocurile din lobby
+//
+// Specification:
+//
+//	Returns:
+//	  - int: numărul total de jucători eliberați
+//	Thread Safety:
+//	  - Funcția este thread-safe; jocurile pot fi jucate în timpul verificării
+func (l *Lobby) ReleaseIdlePlayers() int {
+	released := 0
+	for _, game := range l.Games() {
+		released += len(game.Board.ReleaseIdlePlayers())
+	}
+	return released
+}
+
+// releaseIdlePlayers verifică periodic jucătorii inactivi din toate jocurile
+//
+// Specification:
+//
+//	Parameters:
+//	  - interval: cât de des se verifică (> 0)
+//	Effects:
+//	  - Apelează lobby.ReleaseIdlePlayers la fiecare interval, pentru totdeauna
+func releaseIdlePlayers(lobby *Lobby, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if released := lobby.ReleaseIdlePlayers(); released > 0 {
+			log.Printf("Released %d idle players", released)
+		}
+	}
+}
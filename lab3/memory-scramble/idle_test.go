@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock este un ceas care avansează doar când testul o cere
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Now returnează momentul curent al ceasului
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance mută ceasul înainte cu d
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newIdleBoard creează o tablă cu limita de inactivitate de un minut și ceas fals
+func newIdleBoard() (*Board, *fakeClock) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A")},
+		{NewCard("B"), NewCard("B")},
+	})
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	board.SetClock(clock.Now)
+	board.SetIdleTimeout(time.Minute)
+	return board, clock
+}
+
+// Test that an idle player's first card is released to a waiting player
+func TestIdlePlayerReleasesCard(t *testing.T) {
+	board, clock := newIdleBoard()
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	done := flipAsync(ctx, board, 0, 0, "player2") // 1-D: așteaptă după player1
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 1)
+
+	clock.Advance(59 * time.Second)
+	if idle := board.ReleaseIdlePlayers(); idle != nil {
+		t.Errorf("Expected nobody to be idle yet, got %v", idle)
+	}
+
+	version := board.Version()
+	clock.Advance(time.Second)
+	if idle := board.ReleaseIdlePlayers(); !reflect.DeepEqual(idle, []string{"player1"}) {
+		t.Fatalf("Expected player1 to be idle, got %v", idle)
+	}
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Error("Expected player2 to get the released card")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiting flip was not woken by the idle cleanup")
+	}
+	if board.Version() <= version {
+		t.Error("Expected the idle cleanup to publish a new version")
+	}
+	if board.GetPlayerState("player1").InProgress() {
+		t.Error("Idle player should not hold any card")
+	}
+	if got := board.FormatBoard("player2"); got != "2x2\nmy A\ndown\ndown\ndown\n" {
+		t.Errorf("Unexpected board after the idle cleanup %q", got)
+	}
+}
+
+// Test that an idle player's matched pair is collected and a mismatch turned down
+func TestIdlePlayerCleanup(t *testing.T) {
+	board, clock := newIdleBoard()
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 1, "player1") // 2-D
+	FlipCard(ctx, board, 1, 0, "player2")
+	FlipCard(ctx, board, 0, 0, "player2") // 2-B
+	clock.Advance(time.Minute)
+	board.ReleaseIdlePlayers()
+
+	if got := board.FormatBoard("player1"); got != "2x2\nnone\nnone\nup B\ndown\n" {
+		t.Errorf("Expected the pair removed and player2's card relinquished, got %q", got)
+	}
+	if scores := board.Scores(); scores[0].PlayerID != "player1" || scores[0].Pairs != 1 {
+		t.Errorf("Expected the idle player's pair to be counted, got %+v", scores)
+	}
+}
+
+// Test that the active player loses the turn after the idle timeout
+func TestIdlePlayerLosesTurn(t *testing.T) {
+	board, clock := newIdleBoard()
+	board.SetTurnBased(true)
+	board.Join("player1")
+	board.Join("player2")
+
+	clock.Advance(time.Minute)
+	if idle := board.ReleaseIdlePlayers(); !reflect.DeepEqual(idle, []string{"player1"}) {
+		t.Fatalf("Expected player1 to be idle, got %v", idle)
+	}
+	if active := board.ActivePlayer(); active != "player2" {
+		t.Errorf("Expected the turn to pass to player2, got %q", active)
+	}
+
+	// player2 are un minut întreg de la primirea rândului
+	clock.Advance(30 * time.Second)
+	if idle := board.ReleaseIdlePlayers(); idle != nil {
+		t.Errorf("Expected player2 to still have time, got %v", idle)
+	}
+}
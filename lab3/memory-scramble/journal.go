@@ -0,0 +1,508 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// Operațiile care pot apărea în journal
+const (
+	OpStart   = "start"   // Începutul journal-ului: valorile cărților, matcher-ul și mărimea grupurilor
+	OpFlip    = "flip"    // Un flip, reușit sau nu, cu regula aplicată
+	OpCleanup = "cleanup" // Curățarea turei anterioare a unui jucător (3-A sau 3-B)
+	OpReplace = "replace" // Replace pe cărțile controlate de un jucător
+	OpMap     = "map"     // O valoare înlocuită de map, cu cărțile afectate
+	OpReset   = "reset"   // Un joc nou, cu valorile cărților
+	OpJoin    = "join"    // Un jucător intrat în ordinea turelor (modul pe ture)
+	OpTimeout = "timeout" // Un jucător inactiv care și-a pierdut rândul (modul pe ture)
+)
+
+// JournalEntry este o operație care a modificat tabla, scrisă ca o linie JSON
+type JournalEntry struct {
+	Version int        `json:"version"`          // Versiunea tablei după operație
+	Time    time.Time  `json:"time"`             // Momentul scrierii în journal (UTC)
+	Op      string     `json:"op"`               // Operația (vezi constantele Op...)
+	Player  string     `json:"player,omitempty"` // Jucătorul care a făcut operația (lipsește la reset)
+	Card    *Position  `json:"card,omitempty"`   // Cartea întoarsă (flip)
+	Rule    string     `json:"rule,omitempty"`   // Regula aplicată (flip: 1-A ... 2-E, cleanup: 3-A sau 3-B)
+	From    string     `json:"from,omitempty"`   // Valoarea înlocuită (replace, map)
+	To      string     `json:"to,omitempty"`     // Valoarea nouă (replace, map)
+	Cells   []Position `json:"cells,omitempty"`  // Cărțile înlocuite (map)
+	Layout  [][]string `json:"layout,omitempty"` // Valorile cărților după reset sau la start ("" = celulă goală)
+	Match   string     `json:"match,omitempty"`  // Specificația matcher-ului (start, "" = valori identice)
+	Group   int        `json:"group,omitempty"`  // Mărimea grupurilor (start, 0 = perechi)
+}
+
+// Journal scrie operațiile unei table, câte o linie JSON, în ordinea aplicării lor
+// Representation Invariants:
+//   - w != nil și now != nil
+//
+// Thread Safety:
+//   - Journal nu are lock propriu: append este apelat doar de Board, cu board.mu ținut
+type Journal struct {
+	w      io.Writer        // Destinația liniilor
+	closer io.Closer        // Închis de Close (nil dacă w nu trebuie închis)
+	now    func() time.Time // Ceasul folosit pentru JournalEntry.Time
+}
+
+// NewJournal creează un journal care scrie în w
+//
+// Specification:
+//
+//	Returns:
+//	  - *Journal: journal nou; Close nu închide w
+func NewJournal(w io.Writer) *Journal {
+	return &Journal{w: w, now: time.Now}
+}
+
+// OpenJournal deschide fișierul path pentru adăugare, creându-l dacă lipsește
+//
+// Specification:
+//
+//	Returns:
+//	  - *Journal: journal care adaugă linii la sfârșitul fișierului
+//	  - error: non-nil dacă fișierul nu poate fi deschis
+//	Postconditions:
+//	  - Liniile existente nu sunt modificate niciodată
+//	  - Fiecare linie este scrisă cu un singur Write, fără fsync
+func OpenJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{w: file, closer: file, now: time.Now}, nil
+}
+
+// CreateJournal începe un journal nou în fișierul path
+//
+// Specification:
+//
+//	Returns:
+//	  - *Journal: journal care scrie într-un fișier gol
+//	  - error: non-nil dacă fișierul nu poate fi rotit sau deschis
+//	Postconditions:
+//	  - Un journal existent la path este păstrat ca path + ".1", înlocuind
+//	    rotația anterioară
+func CreateJournal(path string) (*Journal, error) {
+	if err := os.Rename(path, path+".1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return OpenJournal(path)
+}
+
+// Close închide fișierul journal-ului, dacă a fost deschis cu OpenJournal
+func (j *Journal) Close() error {
+	if j.closer == nil {
+		return nil
+	}
+	return j.closer.Close()
+}
+
+// append scrie entry ca o linie JSON
+//
+// Specification:
+//
+//	Returns:
+//	  - error: non-nil dacă scrierea eșuează
+func (j *Journal) append(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(append(line, '\n'))
+	return err
+}
+
+// SetJournal începe să scrie operațiile tablei în journal
+//
+// Specification:
+//
+//	Parameters:
+//	  - journal: destinația operațiilor, sau nil pentru a opri scrierea
+//	Postconditions:
+//	  - Operațiile de acum înainte sunt scrise în journal; cele anterioare nu
+//	  - Journal-ul anterior nu este închis
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) SetJournal(journal *Journal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.journal = journal
+	b.pendingEntries = nil
+}
+
+// StartJournal începe să scrie operațiile tablei în journal, începând cu OpStart
+//
+// Specification:
+//
+//	Parameters:
+//	  - journal: destinația operațiilor (journal nou, ex: din CreateJournal)
+//	Returns:
+//	  - error: non-nil dacă intrarea OpStart nu poate fi scrisă
+//	Preconditions:
+//	  - Tabla nu a fost încă jucată (ex: tocmai încărcată dintr-un fișier)
+//	Postconditions:
+//	  - Prima linie din journal are valorile cărților, matcher-ul și mărimea
+//	    grupurilor, deci replay-ul nu are nevoie de fișierul tablei
+//	  - Operațiile de acum înainte sunt scrise în journal
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) StartJournal(journal *Journal) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.journal = journal
+	b.pendingEntries = nil
+
+	entry := JournalEntry{
+		Version: b.version,
+		Time:    journal.now().UTC(),
+		Op:      OpStart,
+		Layout:  make([][]string, b.Rows),
+		Group:   b.groupSize,
+	}
+	for i, row := range b.Cards {
+		entry.Layout[i] = make([]string, len(row))
+		for j, card := range row {
+			entry.Layout[i][j] = card.Value
+		}
+	}
+	if b.matcher != nil {
+		entry.Match = b.matcher.String()
+	}
+	return journal.append(entry)
+}
+
+// logOperation reține o operație pentru journal, până la următorul commitChanges
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Dacă tabla nu are journal, nu face nimic
+func (b *Board) logOperation(entry JournalEntry) {
+	if b.journal != nil {
+		b.pendingEntries = append(b.pendingEntries, entry)
+	}
+}
+
+// flushJournal scrie operațiile reținute, cu versiunea curentă a tablei
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - pendingEntries este golit
+//	  - Erorile de scriere sunt scrise în log; jocul continuă
+func (b *Board) flushJournal() {
+	for _, entry := range b.pendingEntries {
+		entry.Version = b.version
+		entry.Time = b.journal.now().UTC()
+		if err := b.journal.append(entry); err != nil {
+			log.Printf("Writing journal: %v", err)
+		}
+	}
+	b.pendingEntries = nil
+}
+
+// cleanupRule returnează regula pe care o aplică CleanupPreviousPlay pentru o stare
+//
+// Specification:
+//
+//	Returns:
+//	  - string: "3-A" după un grup complet, "3-B" dacă jucătorul are alte cărți întoarse,
+//	    "" dacă nu e nimic de curățat
+func cleanupRule(state *PlayerState) string {
+	switch {
+	case state.Matched:
+		return "3-A"
+	case len(state.Held) > 0:
+		return "3-B"
+	}
+	return ""
+}
+
+// NewBoardFromJournal construiește tabla de la începutul unui journal
+//
+// Specification:
+//
+//	Parameters:
+//	  - r: journal-ul; doar prima linie este citită
+//	Returns:
+//	  - *Board: tabla din intrarea OpStart, cu matcher-ul și mărimea grupurilor ei,
+//	    la versiunea intrării; Replay aplică apoi tot journal-ul pe ea
+//	  - error: non-nil dacă prima linie nu e o intrare OpStart validă
+func NewBoardFromJournal(r io.Reader) (*Board, error) {
+	line, err := bufio.NewReader(r).ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	var entry JournalEntry
+	if err := json.Unmarshal(line, &entry); err != nil || entry.Op != OpStart {
+		return nil, errors.New("journal does not begin with a start entry")
+	}
+	if len(entry.Layout) == 0 || len(entry.Layout[0]) == 0 {
+		return nil, errors.New("start entry has no cards")
+	}
+
+	cards := make([][]Card, len(entry.Layout))
+	for i := range entry.Layout {
+		cards[i] = make([]Card, len(entry.Layout[0]))
+	}
+	board := NewBoard(cards)
+	if err := board.replayEntry(entry); err != nil {
+		return nil, err
+	}
+	board.layout = entry.Layout
+	board.version = entry.Version
+	board.changeLogBase = entry.Version
+	return board, nil
+}
+
+// Replay aplică pe board operațiile din journal-ul citit din r
+//
+// Specification:
+//
+//	Parameters:
+//	  - board: tabla de la începutul journal-ului (ex: din NewBoardFromJournal
+//	    sau LoadBoardFromFile)
+//	  - r: journal-ul, câte o JournalEntry pe linie
+//	  - upTo: ultima versiune reconstruită, sau -1 pentru tot journal-ul
+//	Returns:
+//	  - int: numărul de operații aplicate
+//	  - error: non-nil dacă o linie nu e validă, versiunile scad sau o operație
+//	    dă alt rezultat decât cel din journal (ex: altă regulă pentru un flip)
+//	Postconditions:
+//	  - board are starea de după ultima operație cu Version <= upTo, inclusiv
+//	    versiunea, scorurile jucătorilor, starea jocului și, dacă journal-ul are
+//	    intrări OpJoin, ordinea turelor și jucătorul activ
+//	  - Change log-ul lui board este gol
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește board.mu); board nu trebuie să aibă journal
+func Replay(board *Board, r io.Reader, upTo int) (int, error) {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+	defer func() {
+		board.changes = nil
+		board.changeLogBase = board.version
+		board.pendingChanges = nil
+		board.turnMoved = false
+	}()
+
+	reader := bufio.NewReader(r)
+	applied := 0
+	for line := 1; ; line++ {
+		text, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return applied, err
+		}
+		if text = bytes.TrimSpace(text); len(text) > 0 {
+			var entry JournalEntry
+			if err := json.Unmarshal(text, &entry); err != nil {
+				return applied, fmt.Errorf("line %d: %w", line, err)
+			}
+			if entry.Version < board.version {
+				return applied, fmt.Errorf("line %d: version %d comes after version %d", line, entry.Version, board.version)
+			}
+			if upTo >= 0 && entry.Version > upTo {
+				return applied, nil
+			}
+			if err := board.replayEntry(entry); err != nil {
+				return applied, fmt.Errorf("line %d: %w", line, err)
+			}
+			board.version = entry.Version
+			board.checkGameOver()
+			applied++
+		}
+		if err != nil {
+			return applied, nil
+		}
+	}
+}
+
+// replayEntry aplică o operație din journal
+//
+// Specification:
+//
+//	Returns:
+//	  - error: non-nil dacă operația nu e validă pentru tablă sau rezultatul ei
+//	    diferă de cel din journal
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+func (b *Board) replayEntry(entry JournalEntry) error {
+	if entry.Op != OpReset && entry.Op != OpStart && !IsValidPlayerID(entry.Player) {
+		return fmt.Errorf("invalid player %q", entry.Player)
+	}
+
+	switch entry.Op {
+	case OpFlip:
+		if entry.Card == nil || !b.InBounds(entry.Card.Row, entry.Card.Col) {
+			return errors.New("flip without a valid card")
+		}
+		state := b.GetPlayerState(entry.Player)
+		state.LastAction = entry.Time
+		if rule, _ := applyFlip(b, *entry.Card, entry.Player, state); rule != entry.Rule {
+			return fmt.Errorf("flip by %s at (%d, %d) applied rule %s, journal has %s",
+				entry.Player, entry.Card.Row, entry.Card.Col, rule, entry.Rule)
+		}
+	case OpCleanup:
+		state := b.GetPlayerState(entry.Player)
+		if rule := cleanupRule(state); rule != entry.Rule {
+			return fmt.Errorf("cleanup for %s applies rule %q, journal has %s", entry.Player, rule, entry.Rule)
+		}
+		CleanupPreviousPlay(b, state, entry.Player)
+	case OpReplace:
+		b.GetPlayerState(entry.Player).LastAction = entry.Time
+		if !ReplaceCards(b, entry.Player, entry.From, entry.To) {
+			return fmt.Errorf("replace %s -> %s by %s changed no card", entry.From, entry.To, entry.Player)
+		}
+	case OpMap:
+		if !IsValidCardValue(entry.To) {
+			return fmt.Errorf("invalid card %q", entry.To)
+		}
+		b.GetPlayerState(entry.Player).LastAction = entry.Time
+		for _, pos := range entry.Cells {
+			if !b.InBounds(pos.Row, pos.Col) || b.Cards[pos.Row][pos.Col].Value != entry.From {
+				return fmt.Errorf("map %s -> %s: no card %s at (%d, %d)", entry.From, entry.To, entry.From, pos.Row, pos.Col)
+			}
+			b.Cards[pos.Row][pos.Col].Value = entry.To
+		}
+	case OpStart:
+		values, err := b.layoutValues(entry.Layout)
+		if err != nil {
+			return err
+		}
+		if entry.Match != "" {
+			matcher, err := ParseMatcher(entry.Match)
+			if err != nil {
+				return err
+			}
+			b.matcher = matcher
+		}
+		if entry.Group != 0 {
+			if entry.Group < 2 || entry.Group > maxGroupSize {
+				return fmt.Errorf("invalid group size %d", entry.Group)
+			}
+			b.groupSize = entry.Group
+		}
+		b.resetCards(values)
+	case OpReset:
+		values, err := b.layoutValues(entry.Layout)
+		if err != nil {
+			return err
+		}
+		b.resetCards(values)
+	case OpJoin:
+		// Doar jocurile pe ture scriu OpJoin
+		b.turnBased = true
+		b.join(entry.Player)
+	case OpTimeout:
+		if b.activePlayer() != entry.Player {
+			return fmt.Errorf("timeout for %s, but it is not their turn", entry.Player)
+		}
+		b.endTurn(entry.Player)
+	default:
+		return fmt.Errorf("unknown operation %q", entry.Op)
+	}
+	return nil
+}
+
+// layoutValues verifică valorile unei intrări OpStart sau OpReset
+//
+// Specification:
+//
+//	Returns:
+//	  - []string: valorile, rând cu rând, pentru resetCards
+//	  - error: non-nil dacă layout nu are dimensiunile tablei sau o valoare nu e
+//	    o carte validă sau "" (celulă goală)
+func (b *Board) layoutValues(layout [][]string) ([]string, error) {
+	if len(layout) != b.Rows {
+		return nil, errors.New("layout does not match the board")
+	}
+	values := make([]string, 0, b.Rows*b.Cols)
+	for _, row := range layout {
+		if len(row) != b.Cols {
+			return nil, errors.New("layout does not match the board")
+		}
+		for _, value := range row {
+			if value != "" && !IsValidCardValue(value) {
+				return nil, fmt.Errorf("invalid card %q in layout", value)
+			}
+		}
+		values = append(values, row...)
+	}
+	return values, nil
+}
+
+// runReplay implementează comanda "replay": reconstruiește o tablă din journal
+// (și, pentru journal-ele fără OpStart, fișierul de start), apoi o afișează la stdout
+//
+// Specification:
+//
+//	Parameters:
+//	  - args: argumentele de după "replay" (-board, -journal, -match, -version, -player, -scores)
+//	Returns:
+//	  - error: non-nil dacă fișierele nu pot fi citite sau journal-ul nu se potrivește cu tabla
+func runReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	boardFile := flags.String("board", "", "fișierul cu tabla de la începutul journal-ului (\"\" = din intrarea start a journal-ului)")
+	journalFile := flags.String("journal", "", "journal-ul jocului (JSON lines)")
+	matchSpec := flags.String("match", "exact", "regula de potrivire folosită de server (-match), dacă tabla nu are #match")
+	groupSize := flags.Int("group", 2, "mărimea grupurilor folosită de server (-group), dacă tabla nu are #group")
+	version := flags.Int("version", -1, "versiunea reconstruită (-1 = ultima din journal)")
+	playerID := flags.String("player", "", "jucătorul din perspectiva căruia se afișează tabla")
+	scores := flags.Bool("scores", false, "afișează și clasamentul")
+	flags.Parse(args)
+
+	if *journalFile == "" {
+		return errors.New("replay: -journal is required")
+	}
+	matcher, err := ParseMatcher(*matchSpec)
+	if err != nil {
+		return err
+	}
+	if *groupSize < 2 || *groupSize > maxGroupSize {
+		return fmt.Errorf("replay: -group must be between 2 and %d", maxGroupSize)
+	}
+	file, err := os.Open(*journalFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var board *Board
+	if *boardFile == "" {
+		if board, err = NewBoardFromJournal(file); err != nil {
+			return fmt.Errorf("%s: %w (use -board for journals without one)", *journalFile, err)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	} else if board, err = LoadBoardFromFile(*boardFile, ParseOptions{GroupSize: *groupSize}); err != nil {
+		return err
+	}
+	board.useDefaultMatcher(matcher)
+	board.useDefaultGroupSize(*groupSize)
+
+	applied, err := Replay(board, file, *version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *journalFile, err)
+	}
+	log.Printf("Replayed %d operations, board is at version %d", applied, board.Version())
+
+	snapshot := board.Snapshot(*playerID)
+	if *scores {
+		snapshot.Scores = board.Scores()
+	}
+	fmt.Print(snapshot.Text())
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newJournalBoard creează tabla folosită de testele de journal, cu journal în buffer
+func newJournalBoard(buffer *bytes.Buffer) *Board {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A"), NewCard("B")},
+		{NewCard("B"), NewCard("C"), NewCard("C")},
+	})
+	if buffer != nil {
+		board.SetJournal(NewJournal(buffer))
+	}
+	return board
+}
+
+// Test that every operation is journaled with its rule and the resulting version
+func TestJournalRecordsOperations(t *testing.T) {
+	var buffer bytes.Buffer
+	board := newJournalBoard(&buffer)
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1") // 1-B
+	FlipCard(ctx, board, 1, 1, "player1") // 2-E
+	FlipCard(ctx, board, 0, 1, "player1") // cleanup 3-B, apoi 1-B
+	Replace(board, "player1", "A", "D")
+
+	var entries []JournalEntry
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var entry JournalEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid journal line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	expected := []struct {
+		op, rule string
+		version  int
+	}{
+		{OpFlip, "1-B", 1},
+		{OpFlip, "2-E", 2},
+		{OpCleanup, "3-B", 3},
+		{OpFlip, "1-B", 4},
+		{OpReplace, "", 5},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d: %s", len(expected), len(entries), buffer.String())
+	}
+	for i, want := range expected {
+		got := entries[i]
+		if got.Op != want.op || got.Rule != want.rule || got.Version != want.version || got.Player != "player1" {
+			t.Errorf("Entry %d: expected %s %s at version %d, got %+v", i, want.op, want.rule, want.version, got)
+		}
+		if got.Time.IsZero() {
+			t.Errorf("Entry %d has no timestamp", i)
+		}
+	}
+	if entries[4].From != "A" || entries[4].To != "D" {
+		t.Errorf("Expected replace A -> D, got %+v", entries[4])
+	}
+}
+
+// Test that replaying the journal rebuilds the final board and any earlier version
+func TestReplayRebuildsBoard(t *testing.T) {
+	var buffer bytes.Buffer
+	board := newJournalBoard(&buffer)
+	ctx := context.Background()
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 1, "player1") // 2-D
+	FlipCard(ctx, board, 0, 2, "player2")
+	FlipCard(ctx, board, 0, 0, "player2") // 2-B: cartea e încă a lui player1
+	midVersion := board.Version()
+	midBoard := board.FormatBoard("player2")
+	FlipCard(ctx, board, 0, 2, "player1") // 3-A, apoi 1-C
+	board.Map("player2", func(card string) (string, error) { return strings.ToLower(card), nil })
+	FlipCard(ctx, board, 1, 0, "player1") // 2-D
+	board.Reset(true)
+	FlipCard(ctx, board, 1, 1, "player2")
+
+	replayed := newJournalBoard(nil)
+	if _, err := Replay(replayed, strings.NewReader(buffer.String()), -1); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	for _, playerID := range []string{"player1", "player2"} {
+		if got, want := replayed.FormatBoard(playerID), board.FormatBoard(playerID); got != want {
+			t.Errorf("Board for %s: expected %q, got %q", playerID, want, got)
+		}
+	}
+	if replayed.Version() != board.Version() {
+		t.Errorf("Expected version %d, got %d", board.Version(), replayed.Version())
+	}
+
+	partial := newJournalBoard(nil)
+	if _, err := Replay(partial, strings.NewReader(buffer.String()), midVersion); err != nil {
+		t.Fatalf("Replay up to %d: %v", midVersion, err)
+	}
+	if got := partial.FormatBoard("player2"); got != midBoard {
+		t.Errorf("Board at version %d: expected %q, got %q", midVersion, midBoard, got)
+	}
+	if partial.Version() != midVersion {
+		t.Errorf("Expected version %d, got %d", midVersion, partial.Version())
+	}
+}
+
+// Test that replay rejects a journal that does not match the starting board
+func TestReplayDetectsMismatch(t *testing.T) {
+	journal := `{"version":1,"op":"flip","player":"player1","card":{"row":0,"col":0},"rule":"1-B"}
+{"version":1,"op":"flip","player":"player1","card":{"row":0,"col":2},"rule":"2-D"}
+`
+	_, err := Replay(newJournalBoard(nil), strings.NewReader(journal), -1)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected a mismatch on line 2, got %v", err)
+	}
+}
+
+// Test that a re-created game starts a new journal that replays without the board file
+func TestJournalRecreatedGame(t *testing.T) {
+	dir := t.TempDir()
+	lobby := NewLobby(".", "", time.Second)
+	lobby.SetJournalDir(dir)
+	ctx := context.Background()
+
+	first, err := lobby.CreateGame("table", newJournalBoard(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	FlipCard(ctx, first.Board, 0, 0, "player1")
+	FlipCard(ctx, first.Board, 0, 1, "player1")
+	lobby.DeleteGame("table")
+
+	board, err := ParseBoard(strings.NewReader("#hole .\n1x3\nx\n.\nx\n"), ParseOptions{GroupSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := lobby.CreateGame("table", board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	FlipCard(ctx, board, 0, 2, "player2")
+
+	path := filepath.Join(dir, "table.journal.jsonl")
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("Expected the first journal to be rotated: %v", err)
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := NewBoardFromJournal(bytes.NewReader(text))
+	if err != nil {
+		t.Fatalf("NewBoardFromJournal: %v", err)
+	}
+	if _, err := Replay(replayed, bytes.NewReader(text), -1); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if got, want := replayed.FormatBoard("player2"), second.Board.FormatBoard("player2"); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if replayed.Version() != second.Board.Version() {
+		t.Errorf("Expected version %d, got %d", second.Board.Version(), replayed.Version())
+	}
+}
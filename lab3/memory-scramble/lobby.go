@@ -0,0 +1,613 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGameID este jocul servit și pe rutele vechi, fără prefix (/look/, /flip/, ...)
+const DefaultGameID = "default"
+
+// maxBoardTextSize este dimensiunea maximă a unei table trimise inline la POST /games
+const maxBoardTextSize = 1 << 20
+
+// Game este un joc găzduit de Lobby, cu tabla, listeners și jucătorii lui
+// Representation Invariants:
+//   - ID este un identificator valid (IsValidPlayerID)
+//   - Board != nil
+//   - handler servește rutele unui Server pentru Board
+type Game struct {
+	ID      string       // Identificatorul jocului din URL (/games/{ID}/...)
+	Board   *Board       // Tabla jocului
+	handler http.Handler // Handler-ele HTTP ale jocului (Server.Handler)
+	journal *Journal     // Journal-ul jocului (nil dacă lobby-ul nu are journalDir)
+}
+
+// Lobby ține evidența tuturor jocurilor găzduite de un server
+// Representation Invariants:
+//   - Pentru tot id, games[id].ID == id
+//   - nextID >= 1
+//
+// Thread Safety:
+//   - games, nextID și setările aplicate jocurilor noi sunt protejate de mu
+//   - Fiecare Board este thread-safe; mu nu este ținut în timpul operațiilor pe table
+type Lobby struct {
+	mu           sync.Mutex       // Protejează games și nextID
+	games        map[string]*Game // Jocurile, după ID
+	nextID       int              // Următorul număr pentru ID-urile generate ("game1", "game2", ...)
+	boardsDir    string           // Directorul din care se încarcă tablele după nume
+	staticDir    string           // Directorul cu fișierele statice sau "" pentru niciunul
+	watchTimeout time.Duration    // Durata maximă a unui /watch/, pentru toate jocurile
+	resetMode    ResetMode        // Reset-ul automat aplicat jocurilor noi
+	resetDelay   time.Duration    // Întârzierea reset-ului automat pentru jocurile noi
+	journalDir   string           // Directorul cu journal-ele jocurilor sau "" pentru niciunul
+	matcher      Matcher          // Matcher-ul tablelor fără directiva #match (nil = valori identice)
+	groupSize    int              // Mărimea grupurilor pentru tablele fără directiva #group (0 = perechi)
+	turnBased    bool             // true dacă jocurile noi sunt pe ture
+	idleTimeout  time.Duration    // Limita de inactivitate pentru jocurile noi (0 = fără limită)
+	sessions     *Sessions        // Token-urile de sesiune cerute de jocurile noi (nil = fără autentificare)
+	adminToken   []byte           // Token-ul cerut de POST /games și DELETE /games/{id} când sessions != nil (nil = nimeni)
+}
+
+// NewLobby creează un lobby fără jocuri
+//
+// Specification:
+//
+//	Parameters:
+//	  - boardsDir: directorul din care POST /games?board={name} încarcă table
+//	  - staticDir: directorul servit la "/", sau "" pentru a nu servi fișiere statice
+//	  - watchTimeout: durata maximă a unui /watch/ (trebuie > 0)
+//	Returns:
+//	  - *Lobby: lobby nou, gol
+func NewLobby(boardsDir, staticDir string, watchTimeout time.Duration) *Lobby {
+	return &Lobby{
+		games:        make(map[string]*Game),
+		nextID:       1,
+		boardsDir:    boardsDir,
+		staticDir:    staticDir,
+		watchTimeout: watchTimeout,
+		resetMode:    ResetNone,
+	}
+}
+
+// SetAutoReset configurează reset-ul automat pentru jocurile create de acum înainte
+//
+// Specification:
+//
+//	Parameters:
+//	  - mode, delay: ca la Board.SetAutoReset
+//	Postconditions:
+//	  - CreateGame aplică mode și delay fiecărei table noi
+//	  - Jocurile existente nu sunt afectate
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) SetAutoReset(mode ResetMode, delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.resetMode = mode
+	l.resetDelay = delay
+}
+
+// SetJournalDir pornește journal-ul pentru jocurile create de acum înainte
+//
+// Specification:
+//
+//	Parameters:
+//	  - dir: directorul (existent) cu journal-ele, sau "" pentru a nu scrie journal
+//	Postconditions:
+//	  - CreateGame scrie operațiile jocului {id} în {dir}/{id}.journal.jsonl
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) SetJournalDir(dir string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.journalDir = dir
+}
+
+// SetMatcher setează matcher-ul implicit pentru jocurile create de acum înainte
+//
+// Specification:
+//
+//	Parameters:
+//	  - matcher: regula de potrivire, sau nil pentru valori identice
+//	Postconditions:
+//	  - CreateGame folosește matcher pentru tablele care nu au directiva #match
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) SetMatcher(matcher Matcher) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.matcher = matcher
+}
+
+// SetGroupSize setează mărimea implicită a grupurilor pentru jocurile create de acum înainte
+//
+// Specification:
+//
+//	Parameters:
+//	  - size: câte cărți formează un grup, 2 <= size <= maxGroupSize
+//	Postconditions:
+//	  - CreateGame folosește size pentru tablele care nu au directiva #group
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) SetGroupSize(size int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.groupSize = size
+}
+
+// SetTurnBased setează dacă jocurile create de acum înainte sunt pe ture
+//
+// Specification:
+//
+//	Postconditions:
+//	  - CreateGame activează modul pe ture (Board.SetTurnBased) pentru tablele noi dacă on
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) SetTurnBased(on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.turnBased = on
+}
+
+// SetIdleTimeout setează limita de inactivitate pentru jocurile create de acum înainte
+//
+// Specification:
+//
+//	Postconditions:
+//	  - CreateGame aplică timeout tablelor noi (vezi Board.SetIdleTimeout)
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) SetIdleTimeout(timeout time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.idleTimeout = timeout
+}
+
+// SetSessions cere token-uri de sesiune în jocurile create de acum înainte
+//
+// Specification:
+//
+//	Postconditions:
+//	  - Serverele jocurilor noi folosesc sessions (vezi Server.SetSessions); toate
+//	    jocurile au același emitent, deci un token e valid pentru jucătorul lui în
+//	    oricare joc
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) SetSessions(sessions *Sessions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sessions = sessions
+}
+
+// SetAdminToken stabilește token-ul care permite crearea și ștergerea jocurilor prin HTTP
+//
+// Specification:
+//
+//	Parameters:
+//	  - token: token-ul administratorului, sau nil
+//	Postconditions:
+//	  - Dacă lobby-ul cere sesiuni (SetSessions), POST /games și DELETE /games/{id}
+//	    cer token-ul (vezi authorizeAdmin); fără token aceste rute sunt refuzate
+//	  - Fără sesiuni, token-ul nu este cerut
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) SetAdminToken(token []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.adminToken = append([]byte(nil), token...)
+}
+
+// authorizeAdmin verifică dacă request-ul poate crea sau șterge jocuri
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă lobby-ul nu cere sesiuni sau request-ul prezintă token-ul
+//	    administratorului (header Authorization: Bearer sau ?token=); altfel
+//	    răspunsul a fost deja trimis: 401 dacă token-ul lipsește sau nu e cel
+//	    corect, 403 dacă lobby-ul nu are token de administrator
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	l.mu.Lock()
+	sessions, adminToken := l.sessions, l.adminToken
+	l.mu.Unlock()
+
+	if sessions == nil {
+		return true
+	}
+	if len(adminToken) == 0 {
+		writeError(w, http.StatusForbidden, "Creating and deleting games is disabled: the server has no -admin-token-file")
+		return false
+	}
+	token := requestToken(r)
+	if token == "" {
+		writeError(w, http.StatusUnauthorized, "Missing admin token")
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(token), adminToken) != 1 {
+		writeError(w, http.StatusUnauthorized, "Invalid admin token")
+		return false
+	}
+	return true
+}
+
+// CreateGame adaugă un joc nou cu tabla dată
+//
+// Specification:
+//
+//	Parameters:
+//	  - id: identificatorul dorit, sau "" pentru unul generat ("game1", "game2", ...)
+//	  - board: tabla jocului (nu trebuie nil, nu trebuie folosită de alt joc)
+//	Returns:
+//	  - *Game: jocul creat
+//	  - error: non-nil dacă id nu e valid, există deja sau journal-ul nu poate fi deschis
+//	Postconditions:
+//	  - Tabla primește setările de reset automat și limita de inactivitate ale
+//	    lobby-ului și, dacă nu le are
+//	    deja din directive, matcher-ul și mărimea grupurilor lobby-ului
+//	  - Dacă lobby-ul e pe ture, jocul este pe ture
+//	  - Dacă lobby-ul are sessions, jocul cere token-uri de sesiune
+//	  - Dacă lobby-ul are journalDir, operațiile tablei sunt scrise într-un journal
+//	    nou al jocului, care începe cu tabla (OpStart); journal-ul unui joc
+//	    anterior cu același ID este rotit (vezi CreateJournal)
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) CreateGame(id string, board *Board) (*Game, error) {
+	return l.createGame(id, board, false)
+}
+
+// createGame implementează CreateGame
+//
+// Specification:
+//
+//	Parameters:
+//	  - restored: true dacă board vine dintr-un snapshot; atunci journal-ul
+//	    existent al jocului este continuat, nu rotit
+func (l *Lobby) createGame(id string, board *Board, restored bool) (*Game, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if id == "" {
+		for {
+			id = "game" + strconv.Itoa(l.nextID)
+			l.nextID++
+			if _, exists := l.games[id]; !exists {
+				break
+			}
+		}
+	} else if !IsValidPlayerID(id) {
+		return nil, fmt.Errorf("invalid game ID %q", id)
+	} else if _, exists := l.games[id]; exists {
+		return nil, fmt.Errorf("game %q already exists", id)
+	}
+
+	board.SetAutoReset(l.resetMode, l.resetDelay)
+	board.SetIdleTimeout(l.idleTimeout)
+	if l.matcher != nil {
+		board.useDefaultMatcher(l.matcher)
+	}
+	if l.groupSize != 0 {
+		board.useDefaultGroupSize(l.groupSize)
+	}
+	if l.turnBased {
+		board.SetTurnBased(true)
+	}
+
+	var journal *Journal
+	if l.journalDir != "" {
+		path := filepath.Join(l.journalDir, id+".journal.jsonl")
+		var err error
+		if restored {
+			if journal, err = OpenJournal(path); err != nil {
+				return nil, err
+			}
+			board.SetJournal(journal)
+		} else {
+			if journal, err = CreateJournal(path); err != nil {
+				return nil, err
+			}
+			if err := board.StartJournal(journal); err != nil {
+				journal.Close()
+				return nil, err
+			}
+		}
+	}
+	server := NewServer(board, "", l.watchTimeout)
+	server.SetSessions(l.sessions)
+	game := &Game{
+		ID:      id,
+		Board:   board,
+		handler: server.Handler(),
+		journal: journal,
+	}
+	l.games[id] = game
+	return game, nil
+}
+
+// Game returnează jocul cu ID-ul dat
+//
+// Specification:
+//
+//	Returns:
+//	  - *Game, true dacă jocul există; nil, false altfel
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) Game(id string) (*Game, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	game, ok := l.games[id]
+	return game, ok
+}
+
+// Games returnează toate jocurile, sortate după ID
+//
+// Specification:
+//
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu); slice-ul returnat este o copie
+func (l *Lobby) Games() []*Game {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	games := make([]*Game, 0, len(l.games))
+	for _, game := range l.games {
+		games = append(games, game)
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].ID < games[j].ID })
+	return games
+}
+
+// DeleteGame șterge jocul cu ID-ul dat
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă jocul exista și a fost șters
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+//	Effects:
+//	  - Request-urile noi pentru jocul șters primesc 404; flip-urile și watch-urile
+//	    care așteaptă se termină cu ErrGameDeleted, celelalte se termină normal
+//	  - Reset-ul automat programat al jocului este anulat (vezi Board.Close)
+//	  - Journal-ul jocului este închis; operațiile în curs nu mai sunt scrise
+func (l *Lobby) DeleteGame(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	game, exists := l.games[id]
+	if !exists {
+		return false
+	}
+	delete(l.games, id)
+	game.Board.Close()
+	if game.journal != nil {
+		game.Board.SetJournal(nil)
+		game.journal.Close()
+	}
+	return true
+}
+
+// Close închide journal-ele tuturor jocurilor, la oprirea serverului
+//
+// Specification:
+//
+//	Postconditions:
+//	  - Operațiile de după Close nu mai sunt scrise în journal; jocurile rămân
+//	    în lobby
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, game := range l.games {
+		if game.journal == nil {
+			continue
+		}
+		game.Board.SetJournal(nil)
+		if err := game.journal.Close(); err != nil {
+			log.Printf("Closing the journal of %s: %v", id, err)
+		}
+		game.journal = nil
+	}
+}
+
+// LoadBoard încarcă o tablă din boardsDir după numele fișierului
+//
+// Specification:
+//
+//	Parameters:
+//	  - name: numele fișierului, fără directoare (ex: "perfect.txt")
+//	Returns:
+//	  - *Board: tabla încărcată
+//	  - error: non-nil dacă numele conține un director, fișierul lipsește sau nu e valid
+func (l *Lobby) LoadBoard(name string) (*Board, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid board name %q", name)
+	}
+	return LoadBoardFromFile(filepath.Join(l.boardsDir, name), l.parseOptions())
+}
+
+// parseOptions returnează verificările cu care lobby-ul încarcă tablele noi
+//
+// Specification:
+//
+//	Returns:
+//	  - ParseOptions: fiecare valoare trebuie să apară de un multiplu de groupSize
+//	    ori (perechi dacă groupSize e 0), pentru tablele fără #group
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (l *Lobby) parseOptions() ParseOptions {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.groupSize == 0 {
+		return ParseOptions{GroupSize: 2}
+	}
+	return ParseOptions{GroupSize: l.groupSize}
+}
+
+// Handler returnează handler-ul HTTP al lobby-ului
+//
+// Specification:
+//
+//	Routes:
+//	  - GET    /games                       listează jocurile, câte unul pe linie: "{id} {R}x{C}"
+//	  - POST   /games[?id={id}]&board={name} creează un joc din fișierul boardsDir/{name}
+//	  - POST   /games[?id={id}]              creează un joc din tabla trimisă în corpul request-ului
+//	                                        (cu &turns, oricare dintre POST-uri creează un joc pe ture)
+//	  - DELETE /games/{id}                  șterge jocul
+//	  - GET    /games/{id}/look/..., /flip/..., /join/..., /logout/..., /watch/..., /replace/..., /map/..., /scores/, /spectate/...
+//	                                        rutele unui Server, pentru jocul {id}
+//	  - /look/, /flip/, ... fără prefix     rutele jocului DefaultGameID
+//	  - /                                   fișierele statice din staticDir
+//	Returns:
+//	  - http.Handler: un ServeMux nou
+func (l *Lobby) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/games", l.handleGames)
+	mux.HandleFunc("/games/", l.handleGame)
+
+	// Rutele vechi servesc jocul implicit
+	defaultGame := func(w http.ResponseWriter, r *http.Request) {
+		game, ok := l.Game(DefaultGameID)
+		if !ok {
+			writeError(w, http.StatusNotFound, "No default game")
+			return
+		}
+		game.handler.ServeHTTP(w, r)
+	}
+	for _, prefix := range []string{"/look/", "/flip/", "/join/", "/logout/", "/watch/", "/replace/", "/map/", "/scores/", "/ws/", "/events/", "/spectate/"} {
+		mux.HandleFunc(prefix, defaultGame)
+	}
+
+	if l.staticDir != "" {
+		mux.Handle("/", getOnly(http.FileServer(http.Dir(l.staticDir)).ServeHTTP))
+	} else {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusNotFound, "Unknown route "+r.URL.Path)
+		})
+	}
+	return mux
+}
+
+// handleGames servește GET /games (listă) și POST /games (creare)
+//
+// Specification:
+//
+//	Response:
+//	  - GET: 200 OK, câte o linie "{id} {R}x{C}" pentru fiecare joc
+//	  - POST: 201 Created, Location: /games/{id}/, corpul este ID-ul jocului
+//	  - 400 Bad Request dacă tabla nu e validă (mesajul include linia și coloana)
+//	  - 409 Conflict dacă ID-ul cerut există deja
+//	  - 401 sau 403 la POST dacă lobby-ul cere sesiuni și request-ul nu are token-ul
+//	    administratorului (vezi authorizeAdmin)
+//	  - 405 Method Not Allowed pentru alte metode
+func (l *Lobby) handleGames(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/plain")
+		for _, game := range l.Games() {
+			fmt.Fprintf(w, "%s %dx%d\n", game.ID, game.Board.Rows, game.Board.Cols)
+		}
+
+	case http.MethodPost:
+		if !l.authorizeAdmin(w, r) {
+			return
+		}
+		id := r.URL.Query().Get("id")
+		if id != "" && !IsValidPlayerID(id) {
+			writeError(w, http.StatusBadRequest, "Invalid game ID "+strconv.Quote(id))
+			return
+		}
+
+		var board *Board
+		var err error
+		if name := r.URL.Query().Get("board"); name != "" {
+			board, err = l.LoadBoard(name)
+		} else {
+			board, err = ParseBoard(io.LimitReader(r.Body, maxBoardTextSize), l.parseOptions())
+		}
+		if err != nil {
+			if os.IsNotExist(err) {
+				err = fmt.Errorf("board %q not found", r.URL.Query().Get("board"))
+			}
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if r.URL.Query().Has("turns") {
+			board.SetTurnBased(true)
+		}
+		game, err := l.CreateGame(id, board)
+		if err != nil {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Location", "/games/"+game.ID+"/")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintln(w, game.ID)
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "Method "+r.Method+" not allowed")
+	}
+}
+
+// handleGame servește DELETE /games/{id} și rutele unui joc: /games/{id}/look/...
+//
+// Specification:
+//
+//	Response:
+//	  - DELETE /games/{id}: 204 No Content, sau 404 dacă jocul nu există; 401 sau
+//	    403 dacă lobby-ul cere sesiuni și request-ul nu are token-ul
+//	    administratorului (vezi authorizeAdmin)
+//	  - /games/{id}/{rută}: răspunsul Server-ului jocului pentru /{rută}
+//	  - 404 Not Found dacă jocul nu există
+func (l *Lobby) handleGame(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/games/")
+	id, route, hasRoute := strings.Cut(rest, "/")
+
+	if !hasRoute || route == "" {
+		// /games/{id} sau /games/{id}/
+		if r.Method != http.MethodDelete {
+			w.Header().Set("Allow", http.MethodDelete)
+			writeError(w, http.StatusMethodNotAllowed, "Method "+r.Method+" not allowed")
+			return
+		}
+		if !l.authorizeAdmin(w, r) {
+			return
+		}
+		if !l.DeleteGame(id) {
+			writeError(w, http.StatusNotFound, "Unknown game "+strconv.Quote(id))
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	game, ok := l.Game(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Unknown game "+strconv.Quote(id))
+		return
+	}
+
+	// Trimite request-ul către Server-ul jocului, fără prefixul /games/{id}
+	inner := r.Clone(r.Context())
+	inner.URL.Path = "/" + route
+	inner.URL.RawPath = ""
+	game.handler.ServeHTTP(w, inner)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLobby pornește un lobby de test cu jocul implicit încărcat din perfect.txt
+func newTestLobby(t *testing.T) *httptest.Server {
+	t.Helper()
+	lobby := NewLobby(".", "", time.Second)
+	board, err := LoadBoardFromFile("perfect.txt", ParseOptions{GroupSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lobby.CreateGame(DefaultGameID, board); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(lobby.Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// do face un request și returnează statusul și corpul răspunsului
+func do(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+// Test creating games, playing them independently, listing and deleting them
+func TestLobbyGameLifecycle(t *testing.T) {
+	server := newTestLobby(t)
+
+	status, body := do(t, http.MethodPost, server.URL+"/games", "1x2\nA\nA\n")
+	if status != http.StatusCreated || body != "game1\n" {
+		t.Fatalf("Expected 201 game1, got %d %q", status, body)
+	}
+	status, _ = do(t, http.MethodPost, server.URL+"/games?id=table2&board=perfect.txt", "")
+	if status != http.StatusCreated {
+		t.Fatalf("Expected 201 for named board, got %d", status)
+	}
+
+	_, body = do(t, http.MethodGet, server.URL+"/games", "")
+	if body != "default 5x5\ngame1 1x2\ntable2 5x5\n" {
+		t.Errorf("Unexpected game list %q", body)
+	}
+
+	status, body = do(t, http.MethodGet, server.URL+"/games/game1/flip/p1/0,0", "")
+	if status != http.StatusOK || body != "1x2\nmy A\ndown\n" {
+		t.Errorf("Unexpected flip in game1: %d %q", status, body)
+	}
+	_, body = do(t, http.MethodGet, server.URL+"/games/table2/look/p1", "")
+	if !strings.HasPrefix(body, "5x5\ndown\n") {
+		t.Errorf("table2 should be untouched, got %q", body)
+	}
+
+	if status, _ = do(t, http.MethodDelete, server.URL+"/games/game1", ""); status != http.StatusNoContent {
+		t.Errorf("Expected 204 on delete, got %d", status)
+	}
+	if status, _ = do(t, http.MethodGet, server.URL+"/games/game1/look/p1", ""); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for deleted game, got %d", status)
+	}
+}
+
+// Test that the old routes still play the default game
+func TestLobbyDefaultGameRoutes(t *testing.T) {
+	server := newTestLobby(t)
+
+	do(t, http.MethodGet, server.URL+"/flip/p1/0,0", "")
+	_, body := do(t, http.MethodGet, server.URL+"/games/default/look/p1", "")
+	if !strings.HasPrefix(body, "5x5\nmy A\n") {
+		t.Errorf("Legacy flip should act on the default game, got %q", body)
+	}
+}
+
+// Test that bad board text, unsafe names and duplicate IDs are rejected
+func TestLobbyRejectsBadGames(t *testing.T) {
+	server := newTestLobby(t)
+
+	status, body := do(t, http.MethodPost, server.URL+"/games", "2x2\nA\nA\n")
+	if status != http.StatusBadRequest || !strings.Contains(body, "line 4") {
+		t.Errorf("Expected 400 with line number, got %d %q", status, body)
+	}
+	if status, body = do(t, http.MethodPost, server.URL+"/games", "1x3\nA\nA\nB\n"); status != http.StatusBadRequest || !strings.Contains(body, "line 4") {
+		t.Errorf("Expected 400 for an odd number of B, got %d %q", status, body)
+	}
+	if status, _ = do(t, http.MethodPost, server.URL+"/games?board=../go.mod", ""); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for path traversal, got %d", status)
+	}
+	if status, _ = do(t, http.MethodPost, server.URL+"/games?board=missing.txt", ""); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for missing board, got %d", status)
+	}
+	if status, _ = do(t, http.MethodPost, server.URL+"/games?id=default", "1x2\nA\nA\n"); status != http.StatusConflict {
+		t.Errorf("Expected 409 for duplicate ID, got %d", status)
+	}
+	if status, _ = do(t, http.MethodGet, server.URL+"/games/nope/look/p1", ""); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown game, got %d", status)
+	}
+}
+
+// Test that deleting a finished game cancels its scheduled reset
+func TestLobbyDeleteStopsReset(t *testing.T) {
+	lobby := NewLobby(".", "", time.Second)
+	lobby.SetAutoReset(ResetReload, 20*time.Millisecond)
+	board := NewBoard([][]Card{{NewCard("A"), NewCard("A")}})
+	if _, err := lobby.CreateGame("table", board); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 1, "player1")
+	if over, _ := board.GameOver(); !over {
+		t.Fatal("Expected the game to be over")
+	}
+
+	version := board.Version()
+	lobby.DeleteGame("table")
+	time.Sleep(100 * time.Millisecond)
+	if board.Version() != version {
+		t.Error("The deleted game should not be reset")
+	}
+}
+
+// Test that with sessions only the admin token can create and delete games
+func TestLobbyGamesNeedAdminToken(t *testing.T) {
+	lobby := NewLobby(".", "", time.Second)
+	lobby.SetSessions(NewSessions([]byte("test secret"), time.Hour))
+	server := httptest.NewServer(lobby.Handler())
+	defer server.Close()
+
+	// Fără token de administrator, nimeni nu poate crea jocuri
+	if status, _ := do(t, http.MethodPost, server.URL+"/games?id=table", "1x2\nA\nA\n"); status != http.StatusForbidden {
+		t.Errorf("Expected 403 without an admin token configured, got %d", status)
+	}
+
+	lobby.SetAdminToken([]byte("admin"))
+	if status, _ := do(t, http.MethodPost, server.URL+"/games?id=table", "1x2\nA\nA\n"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", status)
+	}
+	if status, _ := do(t, http.MethodPost, server.URL+"/games?id=table&token=guess", "1x2\nA\nA\n"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with a wrong token, got %d", status)
+	}
+	if status, _ := do(t, http.MethodPost, server.URL+"/games?id=table&token=admin", "1x2\nA\nA\n"); status != http.StatusCreated {
+		t.Fatalf("Expected 201 with the admin token, got %d", status)
+	}
+
+	if status, _ := do(t, http.MethodDelete, server.URL+"/games/table", ""); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 when deleting without a token, got %d", status)
+	}
+	if _, ok := lobby.Game("table"); !ok {
+		t.Fatal("The game should survive an unauthorized DELETE")
+	}
+	if status, _ := do(t, http.MethodDelete, server.URL+"/games/table?token=admin", ""); status != http.StatusNoContent {
+		t.Errorf("Expected 204 when deleting with the admin token, got %d", status)
+	}
+}
+
+// Test that deleting a game ends the flips and watches waiting on its board
+func TestLobbyDeleteWakesWaiters(t *testing.T) {
+	lobby := NewLobby(".", "", time.Second)
+	board := NewBoard([][]Card{{NewCard("A"), NewCard("B")}})
+	if _, err := lobby.CreateGame("table", board); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := FlipCard(ctx, board, 0, 0, "player1"); err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := make(chan error, 1)
+	go func() { flipped <- FlipCard(ctx, board, 0, 0, "player2") }()
+	watched := make(chan error, 1)
+	go func() {
+		_, err := board.WaitForChange(ctx, board.Version())
+		watched <- err
+	}()
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 1)
+
+	lobby.DeleteGame("table")
+	for name, ch := range map[string]chan error{"flip": flipped, "watch": watched} {
+		select {
+		case err := <-ch:
+			if !errors.Is(err, ErrGameDeleted) {
+				t.Errorf("Expected the waiting %s to end with ErrGameDeleted, got %v", name, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("The waiting %s did not end when the game was deleted", name)
+		}
+	}
+	if _, err := board.WaitForChange(ctx, board.Version()); !errors.Is(err, ErrGameDeleted) {
+		t.Errorf("Expected a new watch on the deleted game to fail, got %v", err)
+	}
+}
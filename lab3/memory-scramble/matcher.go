@@ -0,0 +1,367 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Matcher decide dacă două cărți întoarse de același jucător se potrivesc (regula 2-D)
+//
+// Implementările trebuie să fie relații de echivalență (reflexive, simetrice,
+// tranzitive) și să nu aibă stare mutabilă: Match este apelat cu board.mu ținut.
+type Matcher interface {
+	// Match returnează true dacă valorile a și b formează o pereche
+	Match(a, b string) bool
+	// String returnează specificația matcher-ului, acceptată de ParseMatcher
+	String() string
+}
+
+// ExactMatcher potrivește doar valori identice (regula implicită)
+type ExactMatcher struct{}
+
+// Match implementează Matcher
+func (ExactMatcher) Match(a, b string) bool { return a == b }
+
+// String implementează Matcher
+func (ExactMatcher) String() string { return "exact" }
+
+// CaseInsensitiveMatcher potrivește valorile care diferă doar prin majuscule (ex: "a" și "A")
+type CaseInsensitiveMatcher struct{}
+
+// Match implementează Matcher
+func (CaseInsensitiveMatcher) Match(a, b string) bool { return strings.EqualFold(a, b) }
+
+// String implementează Matcher
+func (CaseInsensitiveMatcher) String() string { return "case-insensitive" }
+
+// ListMatcher potrivește valorile identice și valorile din același grup (ex: "dog" și "🐶")
+// Representation Invariants:
+//   - Fiecare valoare apare într-un singur grup din groups
+//   - group[v] == i pentru fiecare valoare v din groups[i]
+type ListMatcher struct {
+	groups [][]string     // Grupurile de valori echivalente, în ordinea din specificație
+	group  map[string]int // Indexul grupului fiecărei valori
+}
+
+// NewListMatcher creează un ListMatcher din grupuri de valori echivalente
+//
+// Specification:
+//
+//	Parameters:
+//	  - groups: grupuri de cel puțin două valori valide (IsValidCardValue)
+//	Returns:
+//	  - *ListMatcher: matcher-ul pentru grupuri
+//	  - error: non-nil dacă un grup are mai puțin de două valori, o valoare nu e
+//	    validă sau apare în mai multe grupuri
+func NewListMatcher(groups [][]string) (*ListMatcher, error) {
+	m := &ListMatcher{group: make(map[string]int)}
+	for i, group := range groups {
+		if len(group) < 2 {
+			return nil, fmt.Errorf("match group %q needs at least two values", strings.Join(group, "="))
+		}
+		for _, value := range group {
+			if !IsValidCardValue(value) {
+				return nil, fmt.Errorf("invalid card %q in match group", value)
+			}
+			if _, exists := m.group[value]; exists {
+				return nil, fmt.Errorf("card %q appears in more than one match group", value)
+			}
+			m.group[value] = i
+		}
+		m.groups = append(m.groups, append([]string(nil), group...))
+	}
+	return m, nil
+}
+
+// Match implementează Matcher
+func (m *ListMatcher) Match(a, b string) bool {
+	if a == b {
+		return true
+	}
+	groupA, okA := m.group[a]
+	groupB, okB := m.group[b]
+	return okA && okB && groupA == groupB
+}
+
+// String implementează Matcher
+func (m *ListMatcher) String() string {
+	parts := []string{"list"}
+	for _, group := range m.groups {
+		parts = append(parts, strings.Join(group, "="))
+	}
+	return strings.Join(parts, " ")
+}
+
+// MathMatcher potrivește expresiile aritmetice cu aceeași valoare (ex: "2+3" și "5")
+// Cărțile care nu sunt expresii valide se potrivesc doar cu valori identice.
+// Calculele sunt exacte (numere raționale): "1/3+1/3" se potrivește cu "2/3".
+type MathMatcher struct{}
+
+// Match implementează Matcher
+func (MathMatcher) Match(a, b string) bool {
+	if a == b {
+		return true
+	}
+	valueA, errA := EvalExpression(a)
+	valueB, errB := EvalExpression(b)
+	return errA == nil && errB == nil && valueA.Cmp(valueB) == 0
+}
+
+// String implementează Matcher
+func (MathMatcher) String() string { return "math" }
+
+// ParseMatcher creează un matcher din specificația lui
+//
+// Specification:
+//
+//	Parameters:
+//	  - spec: unul dintre
+//	      "exact", "case-insensitive", "math",
+//	      "list {a}={b}[={c}...] ..." (ex: "list dog=🐶 cat=🐱")
+//	Returns:
+//	  - Matcher: matcher-ul descris; m.String() este spec normalizat
+//	  - error: non-nil dacă spec nu are una dintre formele de mai sus
+func ParseMatcher(spec string) (Matcher, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, errors.New("empty matcher")
+	}
+
+	name, args := fields[0], fields[1:]
+	if name != "list" && len(args) > 0 {
+		return nil, fmt.Errorf("matcher %q takes no arguments", name)
+	}
+	switch name {
+	case "exact":
+		return ExactMatcher{}, nil
+	case "case-insensitive":
+		return CaseInsensitiveMatcher{}, nil
+	case "math":
+		return MathMatcher{}, nil
+	case "list":
+		if len(args) == 0 {
+			return nil, errors.New("list matcher needs at least one group, like dog=🐶")
+		}
+		groups := make([][]string, len(args))
+		for i, arg := range args {
+			groups[i] = strings.Split(arg, "=")
+		}
+		return NewListMatcher(groups)
+	}
+	return nil, fmt.Errorf("unknown matcher %q (want exact, case-insensitive, list or math)", name)
+}
+
+// EvalExpression calculează valoarea unei expresii aritmetice
+//
+// Specification:
+//
+//	Parameters:
+//	  - text: expresie cu numere (ex: "12", "1.5"), operatorii + - * / (sau × ÷),
+//	    minus unar și paranteze, fără spații
+//	Returns:
+//	  - *big.Rat: valoarea exactă a expresiei
+//	  - error: non-nil dacă text nu e o expresie validă sau împarte la zero
+func EvalExpression(text string) (*big.Rat, error) {
+	parser := &exprParser{text: []rune(text)}
+	value, err := parser.sum()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(parser.text) {
+		return nil, fmt.Errorf("unexpected %q in expression %q", parser.text[parser.pos], text)
+	}
+	return value, nil
+}
+
+// exprParser este un parser recursive-descent pentru EvalExpression
+//
+//	sum    = term { ("+" | "-") term }
+//	term   = factor { ("*" | "×" | "/" | "÷") factor }
+//	factor = "-" factor | "(" sum ")" | number
+type exprParser struct {
+	text []rune // Expresia
+	pos  int    // Poziția următorului caracter necitit
+}
+
+// peek returnează următorul caracter, sau 0 la sfârșitul expresiei
+func (p *exprParser) peek() rune {
+	if p.pos < len(p.text) {
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+// sum parsează o sumă de termeni
+func (p *exprParser) sum() (*big.Rat, error) {
+	result, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if op == '+' {
+			result.Add(result, right)
+		} else {
+			result.Sub(result, right)
+		}
+	}
+	return result, nil
+}
+
+// term parsează un produs de factori
+func (p *exprParser) term() (*big.Rat, error) {
+	result, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '×' || op == '/' || op == '÷'; op = p.peek() {
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		if op == '*' || op == '×' {
+			result.Mul(result, right)
+		} else if right.Sign() == 0 {
+			return nil, errors.New("division by zero")
+		} else {
+			result.Quo(result, right)
+		}
+	}
+	return result, nil
+}
+
+// factor parsează un număr, o expresie în paranteze sau un factor negat
+func (p *exprParser) factor() (*big.Rat, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		value, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return value.Neg(value), nil
+	case '(':
+		p.pos++
+		value, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, errors.New("missing ')'")
+		}
+		p.pos++
+		return value, nil
+	}
+
+	start := p.pos
+	for r := p.peek(); (r >= '0' && r <= '9') || r == '.'; r = p.peek() {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, fmt.Errorf("expected a number at position %d", start+1)
+	}
+	value, ok := new(big.Rat).SetString(string(p.text[start:p.pos]))
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", string(p.text[start:p.pos]))
+	}
+	return value, nil
+}
+
+// SetMatcher schimbă regula după care se potrivesc cărțile
+//
+// Specification:
+//
+//	Parameters:
+//	  - matcher: noua regulă, sau nil pentru valori identice (ExactMatcher)
+//	Postconditions:
+//	  - Flip-urile de a doua carte de acum înainte folosesc matcher
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) SetMatcher(matcher Matcher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.matcher = matcher
+}
+
+// useDefaultMatcher setează matcher doar dacă tabla nu are deja unul (ex: din directiva #match)
+//
+// Specification:
+//
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) useDefaultMatcher(matcher Matcher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.matcher == nil {
+		b.matcher = matcher
+	}
+}
+
+// matches aplică matcher-ul tablei
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: b.matcher.Match(first, second), sau first == second dacă tabla nu are matcher
+//	Preconditions:
+//	  - Apelantul deține b.mu
+func (b *Board) matches(first, second string) bool {
+	if b.matcher == nil {
+		return first == second
+	}
+	return b.matcher.Match(first, second)
+}
+
+// SetGroupSize schimbă câte cărți potrivite formează un grup
+//
+// Specification:
+//
+//	Parameters:
+//	  - size: numărul de cărți dintr-un grup (2 pentru perechi, 3 pentru triplete, ...)
+//	Preconditions:
+//	  - 2 <= size <= maxGroupSize
+//	Postconditions:
+//	  - Turele începute de acum înainte se încheie cu match după size cărți potrivite
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) SetGroupSize(size int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.groupSize = size
+}
+
+// useDefaultGroupSize setează size doar dacă tabla nu are deja o mărime (ex: din directiva #group)
+//
+// Specification:
+//
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) useDefaultGroupSize(size int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.groupSize == 0 {
+		b.groupSize = size
+	}
+}
+
+// cardsPerGroup returnează câte cărți potrivite încheie o tură cu match
+//
+// Specification:
+//
+//	Returns:
+//	  - int: b.groupSize, sau 2 dacă tabla nu are o mărime setată
+//	Preconditions:
+//	  - Apelantul deține b.mu
+func (b *Board) cardsPerGroup() int {
+	if b.groupSize == 0 {
+		return 2
+	}
+	return b.groupSize
+}
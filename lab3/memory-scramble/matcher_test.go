@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test the pairs accepted and rejected by every built-in matcher
+func TestBuiltinMatchers(t *testing.T) {
+	list, err := NewListMatcher([][]string{{"dog", "🐶"}, {"cat", "🐱", "😺"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		matcher Matcher
+		a, b    string
+		want    bool
+	}{
+		{ExactMatcher{}, "A", "A", true},
+		{ExactMatcher{}, "A", "a", false},
+		{CaseInsensitiveMatcher{}, "Dog", "dOG", true},
+		{CaseInsensitiveMatcher{}, "Ä", "ä", true},
+		{CaseInsensitiveMatcher{}, "dog", "dogs", false},
+		{list, "dog", "🐶", true},
+		{list, "😺", "cat", true},
+		{list, "🐱", "😺", true},
+		{list, "dog", "dog", true},
+		{list, "dog", "cat", false},
+		{list, "fish", "🐟", false},
+		{MathMatcher{}, "2+3", "5", true},
+		{MathMatcher{}, "2×(1+2)", "12÷2", true},
+		{MathMatcher{}, "1/3+1/3", "2/3", true},
+		{MathMatcher{}, "0.5", "1/2", true},
+		{MathMatcher{}, "-2+7", "5", true},
+		{MathMatcher{}, "2+3", "6", false},
+		{MathMatcher{}, "1/0", "1/0", true}, // Valori identice, chiar dacă nu sunt expresii
+		{MathMatcher{}, "1/0", "2/0", false},
+		{MathMatcher{}, "A", "A", true},
+		{MathMatcher{}, "A", "B", false},
+	}
+	for _, c := range cases {
+		if got := c.matcher.Match(c.a, c.b); got != c.want {
+			t.Errorf("%s: Match(%q, %q) = %v, want %v", c.matcher, c.a, c.b, got, c.want)
+		}
+		if got := c.matcher.Match(c.b, c.a); got != c.want {
+			t.Errorf("%s: Match(%q, %q) = %v, want %v (not symmetric)", c.matcher, c.b, c.a, got, c.want)
+		}
+	}
+}
+
+// Test that matcher specs parse and round-trip through String
+func TestParseMatcher(t *testing.T) {
+	for _, spec := range []string{"exact", "case-insensitive", "math", "list dog=🐶 cat=🐱=😺"} {
+		matcher, err := ParseMatcher(spec)
+		if err != nil {
+			t.Errorf("ParseMatcher(%q): %v", spec, err)
+			continue
+		}
+		if matcher.String() != spec {
+			t.Errorf("ParseMatcher(%q).String() = %q", spec, matcher.String())
+		}
+	}
+
+	for _, spec := range []string{"", "fuzzy", "exact now", "list", "list dog", "list dog=🐶 dog=🐕", "list a=b="} {
+		if _, err := ParseMatcher(spec); err == nil {
+			t.Errorf("ParseMatcher(%q) should fail", spec)
+		}
+	}
+}
+
+// Test that a #match directive in the board file drives rule 2-D
+func TestMatchDirective(t *testing.T) {
+	board, err := ParseBoard(strings.NewReader("#match list dog=🐶\n1x4\ndog\nA\n🐶\na\n"), ParseOptions{GroupSize: 2})
+	if err == nil {
+		t.Fatal("Expected A and a to be unmatched groups")
+	}
+
+	board, err = ParseBoard(strings.NewReader("#match case-insensitive\n1x4\ndog\nA\nDOG\na\n"), ParseOptions{GroupSize: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 2, "player1")
+	if got := board.FormatBoard("player1"); got != "1x4\nmy dog\ndown\nmy DOG\ndown\n" {
+		t.Errorf("Expected dog and DOG to match, got %q", got)
+	}
+
+	if _, err := ParseBoard(strings.NewReader("#shuffle\n1x2\nA\nA\n"), ParseOptions{}); err == nil {
+		t.Error("Expected an error for an unknown directive")
+	}
+	if _, err := ParseBoard(strings.NewReader("#match math\n#match exact\n1x2\nA\nA\n"), ParseOptions{}); err == nil {
+		t.Error("Expected an error for a duplicate #match")
+	}
+}
+
+// Test that the lobby matcher applies only to boards without a directive
+func TestLobbyMatcherDefault(t *testing.T) {
+	lobby := NewLobby(".", "", time.Second)
+	lobby.SetMatcher(MathMatcher{})
+
+	plain, _ := ParseBoard(strings.NewReader("1x2\n2+3\n5\n"), ParseOptions{})
+	directive, _ := ParseBoard(strings.NewReader("#match exact\n1x2\n2+3\n5\n"), ParseOptions{})
+	for _, board := range []*Board{plain, directive} {
+		if _, err := lobby.CreateGame("", board); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if plain.matcher.String() != "math" || directive.matcher.String() != "exact" {
+		t.Errorf("Expected math and exact matchers, got %s and %s", plain.matcher, directive.matcher)
+	}
+}
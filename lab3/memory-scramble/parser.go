@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxBoardDimension este numărul maxim de rânduri sau coloane acceptat de parser
+const maxBoardDimension = 1000
+
+// ParseError descrie o eroare de format într-un fișier de tablă
+// Representation Invariants:
+//   - Line >= 1 și Col >= 1
+//   - Msg != ""
+type ParseError struct {
+	Line int    // Linia la care apare eroarea (1-indexed)
+	Col  int    // Coloana la care apare eroarea (1-indexed, în caractere)
+	Msg  string // Descrierea erorii
+}
+
+// Error implementează interfața error
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Line, e.Col, e.Msg)
+}
+
+// ParseOptions configurează verificările suplimentare făcute de ParseBoard
+type ParseOptions struct {
+	// GroupSize, dacă > 0, cere ca fiecare valoare să apară de un multiplu
+	// de GroupSize ori (ex: 2 pentru un joc pe perechi); cu #match se numără
+	// împreună valorile care se potrivesc. Directiva #group are prioritate.
+	GroupSize int
+}
+
+// maxGroupSize este cel mai mare număr de cărți dintr-un grup acceptat de #group
+const maxGroupSize = 8
+
+// boardDirectives sunt setările citite din directivele de dinaintea dimensiunilor
+type boardDirectives struct {
+	matcher   Matcher // Din "#match {spec}" (nil dacă lipsește)
+	groupSize int     // Din "#group {K}" (0 dacă lipsește)
+	hole      string  // Din "#hole {mark}": cărțile egale cu mark sunt celule goale ("" dacă lipsește)
+}
+
+// ParseBoard citește și validează o tablă de joc
+//
+// Specification:
+//
+//	Parameters:
+//	  - r: sursa din care se citește tabla
+//	  - opts: verificări suplimentare (vezi ParseOptions)
+//	Returns:
+//	  - *Board: tabla citită, sau nil dacă apare eroare
+//	  - error: *ParseError dacă formatul e greșit, eroarea de citire altfel
+//	Preconditions:
+//	  - Conținutul trebuie să aibă formatul:
+//	      Opțional, directive de forma "#match {spec}" (vezi ParseMatcher),
+//	      "#group {K}", cu 2 <= K <= maxGroupSize, sau "#hole {mark}"
+//	      Apoi linia "RxC", cu 1 <= R, C <= maxBoardDimension
+//	      Liniile următoare: exact R*C cărți, câte una pe linie
+//	  - O carte este un string nevid fără whitespace
+//	  - După ultima carte pot urma doar linii goale
+//	  - Terminațiile de linie "\r\n" sunt acceptate
+//	Postconditions:
+//	  - Dacă reușește: returnează Board valid, cu toate cărțile cu fața în jos
+//	    cu matcher-ul din directiva #match (nil dacă lipsește) și cu mărimea
+//	    grupurilor din directiva #group (0 dacă lipsește)
+//	  - Dacă există #hole mark, liniile egale cu mark sunt celule deja goale
+//	    (Value == ""), care nu se numără în grupuri; cel puțin o celulă are o carte
+//	  - Dacă există #group K, fiecare valoare apare de un multiplu de K ori
+//	  - Dacă eșuează: returnează nil și error non-nil
+//	Effects:
+//	  - Citește din r până la EOF sau până la prima eroare
+func ParseBoard(r io.Reader, opts ParseOptions) (*Board, error) {
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	// nextLine citește următoarea linie, fără "\r" final
+	nextLine := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		line++
+		return strings.TrimSuffix(scanner.Text(), "\r"), true
+	}
+
+	// Directivele (ex: "#match case-insensitive") pot apărea înaintea dimensiunilor
+	var directives boardDirectives
+	header, ok := nextLine()
+	for ok && strings.HasPrefix(header, "#") {
+		if err := parseDirective(header, line, &directives); err != nil {
+			return nil, err
+		}
+		header, ok = nextLine()
+	}
+	matcher := directives.matcher
+
+	// Citește linia cu dimensiunile (ex: "3x3")
+	if !ok {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		if line > 0 {
+			return nil, &ParseError{Line: line + 1, Col: 1, Msg: "missing dimensions"}
+		}
+		return nil, &ParseError{Line: 1, Col: 1, Msg: "empty file"}
+	}
+	rows, cols, err := parseDimensions(header, line)
+	if err != nil {
+		return nil, err
+	}
+	headerLine := line
+
+	// Citește exact rows*cols cărți
+	values := make([]string, 0, rows*cols)
+	firstLine := make(map[string]int)
+	counts := make(map[string]int)
+	for len(values) < rows*cols {
+		text, ok := nextLine()
+		if !ok {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, &ParseError{
+				Line: line + 1,
+				Col:  1,
+				Msg:  fmt.Sprintf("too few cards: expected %d, got %d", rows*cols, len(values)),
+			}
+		}
+		if err := checkCard(text, line); err != nil {
+			return nil, err
+		}
+		if text == directives.hole {
+			values = append(values, "")
+			continue
+		}
+		if _, seen := firstLine[text]; !seen {
+			firstLine[text] = line
+		}
+		counts[text]++
+		values = append(values, text)
+	}
+
+	// După ultima carte sunt permise doar linii goale
+	for {
+		text, ok := nextLine()
+		if !ok {
+			break
+		}
+		if strings.TrimSpace(text) != "" {
+			return nil, &ParseError{
+				Line: line,
+				Col:  1,
+				Msg:  fmt.Sprintf("too many cards: expected %d", rows*cols),
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return nil, &ParseError{Line: headerLine, Col: 1, Msg: "board has no cards, only holes"}
+	}
+
+	// Verifică grupurile (ex: perechi) dacă se cere; cu un matcher, valorile
+	// care se potrivesc între ele (ex: "dog" și "🐶") se numără împreună
+	groupSize := opts.GroupSize
+	if directives.groupSize > 0 {
+		groupSize = directives.groupSize
+	}
+	if groupSize > 0 {
+		if matcher != nil {
+			counts, firstLine = groupCounts(values, firstLine, matcher)
+		}
+		for _, value := range values {
+			if count, ok := counts[value]; ok && count%groupSize != 0 {
+				return nil, &ParseError{
+					Line: firstLine[value],
+					Col:  1,
+					Msg:  fmt.Sprintf("card %q has %d matching cards, not a multiple of %d", value, count, groupSize),
+				}
+			}
+		}
+	}
+
+	// Creează matricea de cărți; fiecare carte începe cu fața în jos și necontrolată
+	cards := make([][]Card, rows)
+	for i := 0; i < rows; i++ {
+		cards[i] = make([]Card, cols)
+		for j := 0; j < cols; j++ {
+			cards[i][j] = NewCard(values[i*cols+j])
+		}
+	}
+
+	board := NewBoard(cards)
+	board.matcher = matcher
+	board.groupSize = directives.groupSize
+	return board, nil
+}
+
+// parseDirective interpretează o linie de directivă de dinaintea dimensiunilor
+//
+// Specification:
+//
+//	Parameters:
+//	  - text: linia, care începe cu "#"
+//	  - line: numărul liniei
+//	  - directives: setările citite din directivele anterioare
+//	Returns:
+//	  - error: *ParseError dacă directiva e necunoscută, repetată sau invalidă
+//	Postconditions:
+//	  - "#match {spec}" setează directives.matcher (vezi ParseMatcher)
+//	  - "#group {K}" setează directives.groupSize
+//	  - "#hole {mark}" setează directives.hole; mark este o carte validă
+func parseDirective(text string, line int, directives *boardDirectives) error {
+	name, spec, _ := strings.Cut(text[1:], " ")
+	switch name {
+	case "match":
+		if directives.matcher != nil {
+			return &ParseError{Line: line, Col: 1, Msg: "duplicate #match directive"}
+		}
+		matcher, err := ParseMatcher(spec)
+		if err != nil {
+			return &ParseError{Line: line, Col: len("#match ") + 1, Msg: err.Error()}
+		}
+		directives.matcher = matcher
+	case "group":
+		if directives.groupSize != 0 {
+			return &ParseError{Line: line, Col: 1, Msg: "duplicate #group directive"}
+		}
+		size, err := strconv.Atoi(spec)
+		if err != nil || size < 2 || size > maxGroupSize {
+			return &ParseError{
+				Line: line,
+				Col:  len("#group ") + 1,
+				Msg:  fmt.Sprintf("invalid group size %q (want 2 to %d)", spec, maxGroupSize),
+			}
+		}
+		directives.groupSize = size
+	case "hole":
+		if directives.hole != "" {
+			return &ParseError{Line: line, Col: 1, Msg: "duplicate #hole directive"}
+		}
+		if err := checkCard(spec, line); err != nil {
+			return &ParseError{Line: line, Col: len("#hole ") + 1, Msg: fmt.Sprintf("invalid hole mark %q", spec)}
+		}
+		directives.hole = spec
+	default:
+		return &ParseError{Line: line, Col: 2, Msg: fmt.Sprintf("unknown directive %q", name)}
+	}
+	return nil
+}
+
+// groupCounts numără cărțile care se potrivesc între ele, după matcher
+//
+// Specification:
+//
+//	Returns:
+//	  - counts: pentru prima valoare din fiecare grup de valori care se potrivesc,
+//	    numărul de cărți din grup; celelalte valori nu apar în counts
+//	  - firstLine: linia primei cărți din fiecare grup, după aceeași cheie
+func groupCounts(values []string, lines map[string]int, matcher Matcher) (map[string]int, map[string]int) {
+	counts := make(map[string]int)
+	firstLine := make(map[string]int)
+	var keys []string
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		key := ""
+		for _, candidate := range keys {
+			if matcher.Match(candidate, value) {
+				key = candidate
+				break
+			}
+		}
+		if key == "" {
+			key = value
+			keys = append(keys, key)
+			firstLine[key] = lines[value]
+		}
+		counts[key]++
+	}
+	return counts, firstLine
+}
+
+// parseDimensions parsează linia de header "RxC"
+//
+// Specification:
+//
+//	Returns:
+//	  - rows, cols: dimensiunile, dacă error == nil
+//	  - error: *ParseError pe linia line dacă header-ul nu e valid
+//	Postconditions:
+//	  - Dacă error == nil: 1 <= rows, cols <= maxBoardDimension
+func parseDimensions(header string, line int) (int, int, error) {
+	x := strings.IndexByte(header, 'x')
+	if x < 0 {
+		return 0, 0, &ParseError{Line: line, Col: 1, Msg: fmt.Sprintf("expected dimensions RxC, got %q", header)}
+	}
+
+	rows, err := parseDimension(header[:x], line, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	cols, err := parseDimension(header[x+1:], line, len([]rune(header[:x]))+2)
+	if err != nil {
+		return 0, 0, err
+	}
+	return rows, cols, nil
+}
+
+// parseDimension parsează o singură dimensiune din header, aflată la linia line și coloana col
+func parseDimension(text string, line, col int) (int, error) {
+	for i, r := range []rune(text) {
+		if r < '0' || r > '9' {
+			return 0, &ParseError{Line: line, Col: col + i, Msg: fmt.Sprintf("invalid character %q in dimension", r)}
+		}
+	}
+	if text == "" {
+		return 0, &ParseError{Line: line, Col: col, Msg: "missing dimension"}
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 1 || value > maxBoardDimension {
+		return 0, &ParseError{Line: line, Col: col, Msg: fmt.Sprintf("dimension %s must be between 1 and %d", text, maxBoardDimension)}
+	}
+	return value, nil
+}
+
+// checkCard verifică dacă linia text de la numărul line este o carte validă
+//
+// Specification:
+//
+//	Returns:
+//	  - error: nil dacă text e o carte validă (IsValidCardValue), altfel *ParseError
+//	           cu coloana primului caracter whitespace
+func checkCard(text string, line int) error {
+	if text == "" {
+		return &ParseError{Line: line, Col: 1, Msg: "empty card"}
+	}
+	for i, r := range []rune(text) {
+		if unicode.IsSpace(r) {
+			return &ParseError{Line: line, Col: i + 1, Msg: fmt.Sprintf("whitespace in card %q", text)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test that every file in testdata/bad is rejected with the expected position
+func TestParseBoardRejectsBadFiles(t *testing.T) {
+	cases := []struct {
+		file string
+		line int
+		col  int
+	}{
+		{"empty.txt", 1, 1},
+		{"no-x.txt", 1, 1},
+		{"bad-rows.txt", 1, 1},
+		{"missing-cols.txt", 1, 3},
+		{"zero-rows.txt", 1, 1},
+		{"header-trailing-space.txt", 1, 4},
+		{"too-few.txt", 3, 1},
+		{"too-many.txt", 4, 1},
+		{"trailing-garbage.txt", 5, 1},
+		{"space-in-card.txt", 2, 2},
+		{"empty-card.txt", 3, 1},
+		{"odd-pairs.txt", 2, 1},
+		{"group-count.txt", 3, 1},
+		{"group-size.txt", 1, 8},
+		{"only-holes.txt", 2, 1},
+		{"hole-odd.txt", 6, 1},
+	}
+
+	files, err := filepath.Glob(filepath.Join("testdata", "bad", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(cases) {
+		t.Errorf("Expected %d files in testdata/bad, found %d", len(cases), len(files))
+	}
+
+	for _, c := range cases {
+		t.Run(c.file, func(t *testing.T) {
+			file, err := os.Open(filepath.Join("testdata", "bad", c.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			board, err := ParseBoard(file, ParseOptions{GroupSize: 2})
+			if board != nil {
+				t.Error("Expected nil board for a bad file")
+			}
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected *ParseError, got %v", err)
+			}
+			if parseErr.Line != c.line || parseErr.Col != c.col {
+				t.Errorf("Expected error at %d:%d, got %v", c.line, c.col, parseErr)
+			}
+		})
+	}
+}
+
+// Test that a valid board is parsed with CRLF line endings and trailing blank lines
+func TestParseBoardValid(t *testing.T) {
+	board, err := ParseBoard(strings.NewReader("1x4\r\nA\r\n🦄\r\nA\r\n🦄\r\n\r\n"), ParseOptions{GroupSize: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if board.Rows != 1 || board.Cols != 4 {
+		t.Errorf("Expected 1x4, got %dx%d", board.Rows, board.Cols)
+	}
+	if board.Cards[0][1].Value != "🦄" {
+		t.Errorf("Expected 🦄, got %q", board.Cards[0][1].Value)
+	}
+}
+
+// Test that #hole marks pre-removed cells that do not count towards groups
+func TestParseBoardHoles(t *testing.T) {
+	board, err := ParseBoard(strings.NewReader("#hole .\n2x2\nA\n.\nA\n.\n"), ParseOptions{GroupSize: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := board.FormatBoard("player1"); got != "2x2\ndown\nnone\ndown\nnone\n" {
+		t.Errorf("Expected holes to be empty cells, got %q", got)
+	}
+	if _, err := ParseBoard(strings.NewReader("1x2\n.\n.\n"), ParseOptions{GroupSize: 2}); err != nil {
+		t.Errorf("Without #hole, . should be an ordinary card: %v", err)
+	}
+}
+
+// Test that odd card counts are only rejected when a group size is requested
+// (the server always requests one: pairs, -group or #group)
+func TestParseBoardGroupSizeOptional(t *testing.T) {
+	if _, err := ParseBoard(strings.NewReader("1x3\nA\nA\nB\n"), ParseOptions{}); err != nil {
+		t.Errorf("Odd counts should be allowed without GroupSize: %v", err)
+	}
+	if _, err := ParseBoard(strings.NewReader("1x3\nA\nA\nB\n"), ParseOptions{GroupSize: 2}); err == nil {
+		t.Error("Odd counts should be rejected with GroupSize 2")
+	}
+}
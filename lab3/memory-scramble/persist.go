@@ -0,0 +1,387 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// snapshotFormat este versiunea formatului fișierelor de snapshot
+// Se incrementează la orice schimbare incompatibilă a lui savedBoard
+const snapshotFormat = 2
+
+// snapshotSuffix este extensia fișierelor de snapshot din directorul de stare
+const snapshotSuffix = ".snapshot.json"
+
+// savedCard este o carte așa cum apare în snapshot
+type savedCard struct {
+	Value      string `json:"value"`
+	FaceUp     bool   `json:"faceUp"`
+	Controller string `json:"controller,omitempty"`
+}
+
+// savedBoard este conținutul unui fișier de snapshot
+type savedBoard struct {
+	Format    int                     `json:"format"`              // snapshotFormat
+	SavedAt   time.Time               `json:"savedAt"`             // Momentul salvării
+	Version   int                     `json:"version"`             // Versiunea tablei
+	Cards     [][]savedCard           `json:"cards"`               // Cărțile, rând cu rând
+	Layout    [][]string              `json:"layout"`              // Valorile inițiale, pentru reset
+	GameOver  bool                    `json:"gameOver"`            // true dacă jocul s-a terminat
+	Winners   []string                `json:"winners,omitempty"`   // Câștigătorii jocului terminat
+	Players   map[string]*PlayerState `json:"players"`             // Stările jucătorilor
+	Match     string                  `json:"match,omitempty"`     // Specificația matcher-ului ("" = valori identice)
+	GroupSize int                     `json:"groupSize,omitempty"` // Câte cărți formează un grup (0 = perechi)
+	TurnBased bool                    `json:"turnBased,omitempty"` // true dacă jocul e pe ture
+	TurnOrder []string                `json:"turnOrder,omitempty"` // Ordinea turelor (modul pe ture)
+	Turn      int                     `json:"turn,omitempty"`      // Indexul jucătorului activ în TurnOrder
+}
+
+// WriteSnapshot scrie starea completă a tablei ca JSON
+//
+// Specification:
+//
+//	Returns:
+//	  - error: non-nil dacă scrierea eșuează
+//	Postconditions:
+//	  - Sunt scrise cărțile (valoare, față, controller), valorile inițiale, starea
+//	    jocului, stările jucătorilor, matcher-ul, mărimea grupurilor, turele și
+//	    versiunea, toate din același moment
+//	  - Flip-urile care așteaptă (regula 1-D) și listeners nu sunt salvați
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu.RLock, apoi playerStatesMu);
+//	    scrierea în w se face fără lock
+func (b *Board) WriteSnapshot(w io.Writer) error {
+	b.mu.RLock()
+	saved := savedBoard{
+		Format:    snapshotFormat,
+		SavedAt:   time.Now().UTC(),
+		Version:   b.version,
+		Cards:     make([][]savedCard, b.Rows),
+		Layout:    b.layout,
+		GameOver:  b.gameOver,
+		Winners:   b.winners,
+		Players:   make(map[string]*PlayerState),
+		GroupSize: b.groupSize,
+		TurnBased: b.turnBased,
+		TurnOrder: append([]string(nil), b.turnOrder...),
+		Turn:      b.turn,
+	}
+	if b.matcher != nil {
+		saved.Match = b.matcher.String()
+	}
+	for i := 0; i < b.Rows; i++ {
+		saved.Cards[i] = make([]savedCard, b.Cols)
+		for j := 0; j < b.Cols; j++ {
+			card := b.Cards[i][j]
+			saved.Cards[i][j] = savedCard{Value: card.Value, FaceUp: card.FaceUp, Controller: card.Controller}
+		}
+	}
+	b.playerStatesMu.Lock()
+	for playerID, state := range b.playerStates {
+		copied := *state
+		copied.Held = append([]Position(nil), state.Held...)
+		saved.Players[playerID] = &copied
+	}
+	b.playerStatesMu.Unlock()
+	b.mu.RUnlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(saved)
+}
+
+// ReadSnapshot reconstruiește o tablă dintr-un snapshot scris de WriteSnapshot
+//
+// Specification:
+//
+//	Returns:
+//	  - *Board: tabla restaurată, cu aceeași versiune, cărți și jucători
+//	  - error: non-nil dacă formatul nu e snapshotFormat sau starea nu e validă
+//	Postconditions:
+//	  - Change log-ul este gol: clienții cu o versiune mai veche primesc tabla completă
+//	  - Reset-ul automat este dezactivat (se configurează cu SetAutoReset)
+func ReadSnapshot(r io.Reader) (*Board, error) {
+	var saved savedBoard
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return nil, err
+	}
+	if saved.Format != snapshotFormat {
+		return nil, fmt.Errorf("unsupported snapshot format %d (want %d)", saved.Format, snapshotFormat)
+	}
+	if err := saved.validate(); err != nil {
+		return nil, err
+	}
+
+	cards := make([][]Card, len(saved.Cards))
+	for i, row := range saved.Cards {
+		cards[i] = make([]Card, len(row))
+		for j, card := range row {
+			cards[i][j] = Card{Value: card.Value, FaceUp: card.FaceUp, Controller: card.Controller}
+		}
+	}
+	board := NewBoard(cards)
+	if saved.Match != "" {
+		matcher, err := ParseMatcher(saved.Match)
+		if err != nil {
+			return nil, err
+		}
+		board.matcher = matcher
+	}
+	board.groupSize = saved.GroupSize
+	board.turnBased = saved.TurnBased
+	board.turnOrder = saved.TurnOrder
+	board.turn = saved.Turn
+	board.version = saved.Version
+	board.changeLogBase = saved.Version
+	board.layout = saved.Layout
+	board.gameOver = saved.GameOver
+	if saved.GameOver {
+		board.winners = saved.Winners
+	}
+	for playerID, state := range saved.Players {
+		board.playerStates[playerID] = state
+	}
+	board.checkRep()
+	return board, nil
+}
+
+// validate verifică un snapshot citit de pe disc, înainte de a construi tabla
+//
+// Specification:
+//
+//	Returns:
+//	  - error: non-nil dacă dimensiunile, cărțile, valorile inițiale sau stările
+//	    jucătorilor nu formează o tablă validă, inclusiv dacă o carte controlată
+//	    nu e în Held-ul controlorului sau o carte din tura neîncheiată (sau din
+//	    grupul potrivit) a unui jucător nu e controlată de el
+func (s *savedBoard) validate() error {
+	if len(s.Cards) == 0 || len(s.Cards[0]) == 0 {
+		return errors.New("snapshot has no cards")
+	}
+	if s.Version < 0 {
+		return errors.New("snapshot version cannot be negative")
+	}
+	rows, cols := len(s.Cards), len(s.Cards[0])
+	if len(s.Layout) != rows {
+		return errors.New("snapshot layout does not match the board")
+	}
+	inBounds := func(row, col int) bool { return row >= 0 && row < rows && col >= 0 && col < cols }
+	groupSize := s.GroupSize
+	if groupSize == 0 {
+		groupSize = 2
+	} else if groupSize < 2 || groupSize > maxGroupSize {
+		return fmt.Errorf("invalid group size %d", groupSize)
+	}
+
+	for i := 0; i < rows; i++ {
+		if len(s.Cards[i]) != cols || len(s.Layout[i]) != cols {
+			return fmt.Errorf("snapshot row %d has the wrong length", i)
+		}
+		for j := 0; j < cols; j++ {
+			card := s.Cards[i][j]
+			switch {
+			case s.Layout[i][j] != "" && !IsValidCardValue(s.Layout[i][j]): // "" este o celulă goală (#hole)
+				return fmt.Errorf("invalid initial card at (%d, %d)", i, j)
+			case card.Value != "" && !IsValidCardValue(card.Value):
+				return fmt.Errorf("invalid card at (%d, %d)", i, j)
+			case card.Value == "" && (card.FaceUp || card.Controller != ""):
+				return fmt.Errorf("removed card at (%d, %d) is face up or controlled", i, j)
+			case card.Controller != "" && (!card.FaceUp || !IsValidPlayerID(card.Controller)):
+				return fmt.Errorf("invalid controller at (%d, %d)", i, j)
+			}
+		}
+	}
+
+	for playerID, state := range s.Players {
+		if !IsValidPlayerID(playerID) || state == nil {
+			return fmt.Errorf("invalid player %q", playerID)
+		}
+		held := make(map[Position]bool)
+		for _, pos := range state.Held {
+			if !inBounds(pos.Row, pos.Col) {
+				return fmt.Errorf("player %q holds a card outside the board", playerID)
+			}
+			if held[pos] {
+				return fmt.Errorf("player %q holds the same card twice", playerID)
+			}
+			held[pos] = true
+			// Cât timp tura nu s-a încheiat cu nepotrivire, jucătorul controlează tot ce ține
+			if (!state.Done || state.Matched) && s.Cards[pos.Row][pos.Col].Controller != playerID {
+				return fmt.Errorf("player %q holds a card at (%d, %d) they do not control", playerID, pos.Row, pos.Col)
+			}
+		}
+		if len(state.Held) > groupSize || (state.Matched && !state.Done) ||
+			state.Pairs < 0 || state.FailedFlips < 0 {
+			return fmt.Errorf("invalid state for player %q", playerID)
+		}
+	}
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			controller := s.Cards[i][j].Controller
+			if controller == "" {
+				continue
+			}
+			state := s.Players[controller]
+			if state == nil || !slices.Contains(state.Held, Position{Row: i, Col: j}) {
+				return fmt.Errorf("card at (%d, %d) is controlled by %q but not held", i, j, controller)
+			}
+		}
+	}
+
+	joined := make(map[string]bool)
+	for _, playerID := range s.TurnOrder {
+		if !IsValidPlayerID(playerID) || joined[playerID] {
+			return fmt.Errorf("invalid turn order player %q", playerID)
+		}
+		joined[playerID] = true
+	}
+	if s.Turn < 0 || (s.Turn > 0 && s.Turn >= len(s.TurnOrder)) {
+		return fmt.Errorf("invalid turn %d", s.Turn)
+	}
+	return nil
+}
+
+// SaveSnapshot scrie atomic snapshot-ul tablei în fișierul path
+//
+// Specification:
+//
+//	Returns:
+//	  - error: non-nil dacă fișierul nu poate fi scris
+//	Postconditions:
+//	  - path conține fie snapshot-ul vechi, fie pe cel nou complet, niciodată
+//	    unul scris pe jumătate: se scrie un fișier temporar în același director,
+//	    se sincronizează pe disc și apoi se redenumește peste path
+//	  - Directorul este sincronizat după redenumire, ca redenumirea să rămână
+//	    pe disc și după o cădere de curent
+func (b *Board) SaveSnapshot(path string) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name()) // Nu are efect după Rename
+
+	if err := b.WriteSnapshot(temp); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir sincronizează pe disc intrările directorului dir (ex: după un Rename)
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+// LoadSnapshot citește o tablă salvată cu SaveSnapshot
+//
+// Specification:
+//
+//	Returns:
+//	  - *Board, error: ca la ReadSnapshot; error non-nil și dacă fișierul lipsește
+func LoadSnapshot(path string) (*Board, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	board, err := ReadSnapshot(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return board, nil
+}
+
+// SaveSnapshots salvează toate jocurile în dir, câte un fișier {id}.snapshot.json
+//
+// Specification:
+//
+//	Returns:
+//	  - error: prima eroare întâlnită; celelalte jocuri sunt salvate oricum
+//	Postconditions:
+//	  - Fiecare joc are snapshot-ul curent în dir (scris atomic, vezi Board.SaveSnapshot)
+//	  - Snapshot-urile jocurilor șterse între timp sunt eliminate din dir
+//	Thread Safety:
+//	  - Funcția este thread-safe; jocurile pot fi jucate în timpul salvării
+func (l *Lobby) SaveSnapshots(dir string) error {
+	var firstErr error
+	saved := make(map[string]bool)
+	for _, game := range l.Games() {
+		name := game.ID + snapshotSuffix
+		if err := game.Board.SaveSnapshot(filepath.Join(dir, name)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		saved[name] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), snapshotSuffix) && !saved[entry.Name()] {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+	return firstErr
+}
+
+// RestoreSnapshots creează câte un joc pentru fiecare snapshot din dir
+//
+// Specification:
+//
+//	Returns:
+//	  - int: numărul de jocuri restaurate
+//	  - error: non-nil dacă dir nu poate fi citit sau un snapshot nu e valid
+//	Postconditions:
+//	  - Jocul {id} are tabla din {id}.snapshot.json
+//	  - Dacă dir nu există, nu se restaurează nimic și nu e eroare
+func (l *Lobby) RestoreSnapshots(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), snapshotSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		board, err := LoadSnapshot(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return restored, err
+		}
+		if _, err := l.createGame(id, board, true); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	return restored, nil
+}
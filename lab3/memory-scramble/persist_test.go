@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test that a snapshot restores cards, controllers, players and version
+func TestSnapshotRoundTrip(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A"), NewCard("B")},
+		{NewCard("B"), NewCard("C"), NewCard("C")},
+	})
+	ctx := context.Background()
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 1, "player1")
+	FlipCard(ctx, board, 0, 2, "player1") // 3-A: perechea A e colectată, B e prima carte
+	FlipCard(ctx, board, 1, 1, "player2")
+
+	var buffer bytes.Buffer
+	if err := board.WriteSnapshot(&buffer); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+	restored, err := ReadSnapshot(&buffer)
+	if err != nil {
+		t.Fatalf("ReadSnapshot: %v", err)
+	}
+
+	for _, playerID := range []string{"player1", "player2"} {
+		if got, want := restored.FormatBoard(playerID), board.FormatBoard(playerID); got != want {
+			t.Errorf("Board for %s: expected %q, got %q", playerID, want, got)
+		}
+	}
+	if restored.Version() != board.Version() {
+		t.Errorf("Expected version %d, got %d", board.Version(), restored.Version())
+	}
+	if scores := restored.Scores(); scores[0].PlayerID != "player1" || scores[0].Pairs != 1 {
+		t.Errorf("Unexpected restored scores %+v", scores)
+	}
+
+	// player1 continuă tura: a doua carte B se potrivește cu prima
+	if err := FlipCard(ctx, restored, 1, 0, "player1"); err != nil {
+		t.Fatalf("Flip after restore failed: %v", err)
+	}
+	if got := restored.FormatBoard("player1"); !strings.HasPrefix(got, "2x3\nnone\nnone\nmy B\nmy B\n") {
+		t.Errorf("Unexpected board after restore %q", got)
+	}
+
+	// O tablă cu #hole are celule goale și în valorile inițiale
+	holes, err := ParseBoard(strings.NewReader("#hole .\n1x3\nA\n.\nA\n"), ParseOptions{GroupSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer.Reset()
+	if err := holes.WriteSnapshot(&buffer); err != nil {
+		t.Fatalf("WriteSnapshot with holes: %v", err)
+	}
+	restored, err = ReadSnapshot(&buffer)
+	if err != nil {
+		t.Fatalf("ReadSnapshot with holes: %v", err)
+	}
+	if got := restored.FormatBoard("player1"); got != "1x3\ndown\nnone\ndown\n" {
+		t.Errorf("Unexpected restored board with holes %q", got)
+	}
+}
+
+// Test that invalid snapshots are rejected
+func TestReadSnapshotRejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"format":     `{"format": 99, "cards": [[{"value": "A"}]], "layout": [["A"]]}`,
+		"controller": `{"format": 2, "cards": [[{"value": "A", "controller": "p1"}]], "layout": [["A"]]}`,
+		"removed":    `{"format": 2, "cards": [[{"value": "", "faceUp": true}]], "layout": [["A"]]}`,
+		"ragged":     `{"format": 2, "cards": [[{"value": "A"}], []], "layout": [["A"], []]}`,
+		"player":     `{"format": 2, "cards": [[{"value": "A"}]], "layout": [["A"]], "players": {"p1": {"Held": [{"row": 3, "col": 0}]}}}`,
+		"json":       `{"format": 2,`,
+		"not held":   `{"format": 2, "cards": [[{"value": "A", "faceUp": true, "controller": "p1"}]], "layout": [["A"]], "players": {"p1": {}}}`,
+		"held":       `{"format": 2, "cards": [[{"value": "A", "faceUp": true, "controller": "p2"}]], "layout": [["A"]], "players": {"p1": {"Held": [{"row": 0, "col": 0}]}, "p2": {}}}`,
+	}
+	for name, text := range tests {
+		if _, err := ReadSnapshot(strings.NewReader(text)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// Test that the lobby saves every game atomically and restores them
+func TestLobbySnapshots(t *testing.T) {
+	dir := t.TempDir()
+	lobby := NewLobby(".", "", time.Second)
+	board := NewBoard([][]Card{{NewCard("A"), NewCard("A")}})
+	lobby.CreateGame(DefaultGameID, board)
+	lobby.CreateGame("other", NewBoard([][]Card{{NewCard("B"), NewCard("B")}}))
+	FlipCard(context.Background(), board, 0, 0, "player1")
+
+	// Snapshot-ul unui joc șters trebuie eliminat
+	os.WriteFile(filepath.Join(dir, "deleted"+snapshotSuffix), []byte("{}"), 0o644)
+
+	if err := lobby.SaveSnapshots(dir); err != nil {
+		t.Fatalf("SaveSnapshots: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("Expected exactly two snapshot files, got %v", entries)
+	}
+
+	restoredLobby := NewLobby(".", "", time.Second)
+	if n, err := restoredLobby.RestoreSnapshots(dir); err != nil || n != 2 {
+		t.Fatalf("RestoreSnapshots: %d, %v", n, err)
+	}
+	game, ok := restoredLobby.Game(DefaultGameID)
+	if !ok {
+		t.Fatal("Default game was not restored")
+	}
+	if got := game.Board.FormatBoard("player1"); got != "1x2\nmy A\ndown\n" {
+		t.Errorf("Unexpected restored board %q", got)
+	}
+
+	if n, err := NewLobby(".", "", time.Second).RestoreSnapshots(filepath.Join(dir, "missing")); err != nil || n != 0 {
+		t.Errorf("Missing directory should restore nothing, got %d, %v", n, err)
+	}
+}
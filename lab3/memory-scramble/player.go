@@ -1,16 +1,20 @@
 package main
 
+import "time"
+
 // PlayerState ține evidența stării unui jucător în timpul jocului
 // Representation Invariants:
-//   - Dacă HasSecond == true atunci HasFirst == false
+//   - Held nu conține poziții duplicate
+//   - Dacă Matched == true atunci Done == true
+//   - Dacă Done == false, cărțile din Held sunt controlate de jucător (verificat de Board)
+//   - Pairs >= 0 și FailedFlips >= 0
 type PlayerState struct {
-	FirstCardRow  int  // Rândul primei cărți (-1 dacă nu există)
-	FirstCardCol  int  // Coloana primei cărți (-1 dacă nu există)
-	SecondCardRow int  // Rândul celei de-a doua cărți (-1 dacă nu există)
-	SecondCardCol int  // Coloana celei de-a doua cărți (-1 dacă nu există)
-	HasFirst      bool // true dacă jucătorul are prima carte întorsă
-	HasSecond     bool // true dacă jucătorul are a doua carte întorsă
-	Matched       bool // true dacă cele două cărți se potrivesc
+	Held        []Position // Cărțile întoarse în tura curentă, în ordinea flip-urilor
+	Done        bool       // true dacă tura s-a încheiat (grup complet sau nepotrivire) și așteaptă cleanup
+	Matched     bool       // true dacă toate cărțile din Held formează un grup potrivit
+	Pairs       int        // Numărul de grupuri colectate (perechi când grupul are 2 cărți, regula 3-A)
+	FailedFlips int        // Numărul de flip-uri eșuate
+	LastAction  time.Time  // Momentul ultimei acțiuni (flip, replace, map)
 }
 
 // NewPlayerState creează o stare nouă pentru un jucător
@@ -20,19 +24,21 @@ type PlayerState struct {
 //	Preconditions: none
 //	Postconditions:
 //	  - Returnează un pointer către PlayerState nou alocat
-//	  - Toate câmpurile row/col sunt setate la -1
-//	  - Toate câmpurile bool sunt setate la false
+//	  - Jucătorul nu ține nicio carte (Held gol, Done și Matched false)
 //	  - Obiectul returnat respectă representation invariants
 func NewPlayerState() *PlayerState {
-	return &PlayerState{
-		FirstCardRow:  -1,
-		FirstCardCol:  -1,
-		SecondCardRow: -1,
-		SecondCardCol: -1,
-		HasFirst:      false,
-		HasSecond:     false,
-		Matched:       false,
-	}
+	return &PlayerState{}
+}
+
+// InProgress raportează dacă jucătorul are o tură începută și neîncheiată
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă jucătorul ține cel puțin o carte și tura nu s-a încheiat;
+//	    următorul flip continuă tura (regula 2), altfel începe una nouă (regula 1)
+func (p *PlayerState) InProgress() bool {
+	return len(p.Held) > 0 && !p.Done
 }
 
 // checkRep verifică representation invariants pentru PlayerState
@@ -44,9 +50,48 @@ func NewPlayerState() *PlayerState {
 //	  - Dacă invarianții sunt violați, funcția face panic
 //	  - Dacă invarianții sunt respectați, funcția returnează normal
 //	Effects:
-//	  - Poate face panic dacă HasSecond == true și HasFirst == true
+//	  - Poate face panic dacă Held are duplicate sau Matched == true fără Done
+//	  - Poate face panic dacă Pairs sau FailedFlips sunt negative
 func (p *PlayerState) checkRep() {
-	if p.HasSecond && p.HasFirst {
-		panic("Player cannot have both HasFirst and HasSecond true")
+	seen := make(map[Position]bool)
+	for _, pos := range p.Held {
+		if seen[pos] {
+			panic("Player cannot hold the same card twice")
+		}
+		seen[pos] = true
+	}
+	if p.Matched && !p.Done {
+		panic("Player cannot have a matched group before the turn is done")
+	}
+	if p.Pairs < 0 || p.FailedFlips < 0 {
+		panic("Player statistics cannot be negative")
 	}
 }
+
+// IsValidPlayerID verifică dacă un string poate fi identificatorul unui jucător
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă playerID e nevid și conține doar litere ASCII, cifre sau '_'
+func IsValidPlayerID(playerID string) bool {
+	if playerID == "" {
+		return false
+	}
+	for _, r := range playerID {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !isDigit && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// PlayerScore este statistica publică a unui jucător, folosită pentru clasament
+type PlayerScore struct {
+	PlayerID    string    `json:"player"`      // Identificatorul jucătorului
+	Pairs       int       `json:"pairs"`       // Grupuri (perechi) colectate
+	FailedFlips int       `json:"failedFlips"` // Flip-uri eșuate
+	LastAction  time.Time `json:"lastAction"`  // Momentul ultimei acțiuni
+}
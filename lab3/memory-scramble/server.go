@@ -1,62 +1,344 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
-var board *Board
+// Server servește jocul Memory Scramble prin HTTP pentru o singură tablă
+// Representation Invariants:
+//   - board != nil
+//   - watchTimeout > 0
+//
+// Thread Safety:
+//   - Server nu are stare mutabilă proprie după SetSessions; toate handler-ele
+//     folosesc board și sessions, care sunt thread-safe
+type Server struct {
+	board        *Board        // Tabla servită de acest server
+	staticDir    string        // Directorul cu fișierele statice (index.html) sau "" pentru niciunul
+	watchTimeout time.Duration // Durata maximă a unui request /watch/ fără nicio schimbare
+	sessions     *Sessions     // Emitentul token-urilor de sesiune, sau nil dacă nu se cere autentificare
+}
+
+// NewServer creează un server pentru tabla dată
+//
+// Specification:
+//
+//	Parameters:
+//	  - board: tabla servită (nu trebuie nil)
+//	  - staticDir: directorul servit la "/", sau "" pentru a nu servi fișiere statice
+//	  - watchTimeout: durata maximă a unui /watch/ (trebuie > 0)
+//	Returns:
+//	  - *Server: server nou care respectă representation invariants
+func NewServer(board *Board, staticDir string, watchTimeout time.Duration) *Server {
+	return &Server{
+		board:        board,
+		staticDir:    staticDir,
+		watchTimeout: watchTimeout,
+	}
+}
+
+// SetSessions cere token-uri de sesiune pentru operațiile care modifică tabla
+//
+// Specification:
+//
+//	Parameters:
+//	  - sessions: emitentul token-urilor, sau nil pentru a nu cere autentificare
+//	Preconditions:
+//	  - Serverul nu servește încă request-uri
+//	Postconditions:
+//	  - /join/ emite token-uri, /logout/ le revocă, iar flip, replace și map
+//	    sunt refuzate fără token-ul jucătorului; look, watch, events și scores
+//	    rămân deschise spectatorilor
+func (s *Server) SetSessions(sessions *Sessions) {
+	s.sessions = sessions
+}
+
+// Handler returnează handler-ul HTTP cu toate endpoint-urile serverului
+//
+// Specification:
+//
+//	Returns:
+//	  - http.Handler: un ServeMux nou; mai multe servere pot rula în același proces
+//	Postconditions:
+//	  - Rutele necunoscute primesc 404, metodele diferite de GET primesc 405
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/look/", getOnly(s.handleLook))
+	mux.HandleFunc("/flip/", getOnly(s.handleFlip))
+	mux.HandleFunc("/join/", getOnly(s.handleJoin))
+	mux.HandleFunc("/logout/", getOnly(s.handleLogout))
+	mux.HandleFunc("/watch/", getOnly(s.handleWatch))
+	mux.HandleFunc("/replace/", getOnly(s.handleReplace))
+	mux.HandleFunc("/map/", getOnly(s.handleMap))
+	mux.HandleFunc("/scores/", getOnly(s.handleScores))
+	mux.HandleFunc("/ws/", getOnly(s.handleWebSocket))
+	mux.HandleFunc("/events/", getOnly(s.handleEvents))
+	mux.HandleFunc("/spectate/look/", getOnly(s.handleSpectateLook))
+	mux.HandleFunc("/spectate/watch/", getOnly(s.handleSpectateWatch))
+	mux.HandleFunc("/spectate/events/", getOnly(s.handleSpectateEvents))
+	if s.staticDir != "" {
+		mux.Handle("/", getOnly(http.FileServer(http.Dir(s.staticDir)).ServeHTTP))
+	} else {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusNotFound, "Unknown route "+r.URL.Path)
+		})
+	}
+	return mux
+}
 
 func main() {
-	var err error
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "gen" {
+		if err := runGen(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	boardFile := flag.String("board", "perfect.txt", "fișierul cu tabla jocului implicit")
+	boardsDir := flag.String("boards-dir", ".", "directorul din care POST /games?board= încarcă table")
+	addr := flag.String("addr", ":8080", "adresa pe care ascultă serverul")
+	staticDir := flag.String("static", ".", "directorul cu fișierele statice (\"\" pentru niciunul)")
+	watchTimeout := flag.Duration("watch-timeout", 30*time.Second, "durata maximă a unui request /watch/")
+	resetName := flag.String("reset", "none", "ce se întâmplă după terminarea jocului: none, reload sau shuffle")
+	resetDelay := flag.Duration("reset-delay", 10*time.Second, "cât rămâne vizibilă tabla finală înainte de reset")
+	tcpAddr := flag.String("tcp", "", "adresa protocolului TCP pe linii pentru jocul implicit (\"\" = dezactivat)")
+	stateDir := flag.String("state-dir", "", "directorul cu snapshot-urile jocurilor (\"\" = fără persistență)")
+	snapshotInterval := flag.Duration("snapshot-interval", time.Minute, "cât de des se salvează snapshot-urile")
+	matchSpec := flag.String("match", "exact", "regula de potrivire pentru tablele fără #match: exact, case-insensitive, math sau \"list a=b ...\"")
+	journalDir := flag.String("journal-dir", "", "directorul cu journal-ele jocurilor (\"\" = fără journal)")
+	idleTimeout := flag.Duration("idle-timeout", 0, "după cât timp de inactivitate sunt eliberate cărțile unui jucător (0 = niciodată)")
+	turnBased := flag.Bool("turns", false, "jocurile sunt pe ture: doar jucătorul la rând poate întoarce cărți")
+	groupSize := flag.Int("group", 2, "câte cărți potrivite formează un grup, pentru tablele fără #group")
+	secretFile := flag.String("session-secret-file", "", "fișierul cu secretul token-urilor de sesiune (\"\" = fără autentificare)")
+	sessionTTL := flag.Duration("session-ttl", 12*time.Hour, "cât timp este valid un token de sesiune")
+	adminTokenFile := flag.String("admin-token-file", "", "fișierul cu token-ul care permite POST /games și DELETE /games/{id} când serverul cere sesiuni")
+	flag.Parse()
 
-	// Încarcă tabla din fișier
-	board, err = LoadBoardFromFile("perfect.txt")
+	if *watchTimeout <= 0 {
+		log.Fatal("-watch-timeout must be positive")
+	}
+	resetMode, err := ParseResetMode(*resetName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *resetDelay < 0 {
+		log.Fatal("-reset-delay cannot be negative")
+	}
+	if *snapshotInterval <= 0 {
+		log.Fatal("-snapshot-interval must be positive")
+	}
+	if *idleTimeout < 0 {
+		log.Fatal("-idle-timeout cannot be negative")
+	}
+	matcher, err := ParseMatcher(*matchSpec)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if *groupSize < 2 || *groupSize > maxGroupSize {
+		log.Fatalf("-group must be between 2 and %d", maxGroupSize)
+	}
+	if *sessionTTL <= 0 {
+		log.Fatal("-session-ttl must be positive")
+	}
+	var sessions *Sessions
+	if *secretFile != "" {
+		secret, err := readSecret(*secretFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sessions = NewSessions(secret, *sessionTTL)
+	}
+	var adminToken []byte
+	if *adminTokenFile != "" {
+		if adminToken, err = readSecret(*adminTokenFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	lobby := NewLobby(*boardsDir, *staticDir, *watchTimeout)
+	lobby.SetAutoReset(resetMode, *resetDelay)
+	lobby.SetMatcher(matcher)
+	lobby.SetGroupSize(*groupSize)
+	lobby.SetTurnBased(*turnBased)
+	lobby.SetIdleTimeout(*idleTimeout)
+	lobby.SetSessions(sessions)
+	lobby.SetAdminToken(adminToken)
+	if *journalDir != "" {
+		if err := os.MkdirAll(*journalDir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+		lobby.SetJournalDir(*journalDir)
+	}
+
+	// Restaurează jocurile din ultimele snapshot-uri
+	if *stateDir != "" {
+		restored, err := lobby.RestoreSnapshots(*stateDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Restored %d games from %s", restored, *stateDir)
+	}
+
+	// Încarcă tabla jocului implicit din fișier, dacă nu a fost restaurată
+	if _, ok := lobby.Game(DefaultGameID); !ok {
+		board, err := LoadBoardFromFile(*boardFile, ParseOptions{GroupSize: *groupSize})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := lobby.CreateGame(DefaultGameID, board); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *stateDir != "" {
+		if err := os.MkdirAll(*stateDir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+		go saveSnapshots(lobby, *stateDir, *snapshotInterval)
+	}
+	go shutdownOnSignal(lobby, *stateDir)
+
+	// Jucătorii inactivi sunt verificați de câteva ori pe durata limitei
+	if *idleTimeout > 0 {
+		go releaseIdlePlayers(lobby, *idleTimeout/4)
+	}
 
-	// Configurează endpoints
-	http.HandleFunc("/look/", handleLook)
-	http.HandleFunc("/flip/", handleFlip)
-	http.HandleFunc("/watch/", handleWatch)
-	http.HandleFunc("/replace/", handleReplace)
-	http.Handle("/", http.FileServer(http.Dir(".")))
+	// Protocolul TCP folosește jocul implicit din lobby, deci jucătorii pot amesteca
+	// transporturile și TCP urmează jocul implicit dacă este șters și creat din nou
+	if *tcpAddr != "" {
+		tcpServer := NewLobbyTCPServer(lobby, DefaultGameID)
+		tcpServer.SetSessions(sessions)
+		if _, err := tcpServer.ListenTCP(*tcpAddr); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("TCP line protocol on %s", *tcpAddr)
+	}
 
 	// Pornește serverul
-	log.Println("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	log.Printf("Server starting on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, lobby.Handler()))
+}
+
+// readSecret citește un secret dintr-un fișier (ex: -session-secret-file)
+//
+// Specification:
+//
+//	Returns:
+//	  - []byte: conținutul fișierului, fără spațiile de la capete
+//	  - error: non-nil dacă fișierul nu poate fi citit sau este gol
+func readSecret(filename string) ([]byte, error) {
+	secret, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if secret = bytes.TrimSpace(secret); len(secret) == 0 {
+		return nil, fmt.Errorf("secret file %s is empty", filename)
+	}
+	return secret, nil
+}
+
+// saveSnapshots salvează jocurile din lobby în dir periodic
+//
+// Specification:
+//
+//	Effects:
+//	  - Salvează toate jocurile la fiecare interval (vezi Lobby.SaveSnapshots)
+//	  - Erorile de salvare sunt scrise în log; serverul continuă
+func saveSnapshots(lobby *Lobby, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := lobby.SaveSnapshots(dir); err != nil {
+			log.Printf("Saving snapshots: %v", err)
+		}
+	}
+}
+
+// shutdownOnSignal oprește serverul la SIGTERM sau SIGINT
+//
+// Specification:
+//
+//	Parameters:
+//	  - dir: directorul cu snapshot-uri (-state-dir) sau "" dacă nu se salvează
+//	Effects:
+//	  - Dacă dir != "", salvează încă o dată toate jocurile
+//	  - Închide journal-ele jocurilor (vezi Lobby.Close), apoi oprește procesul;
+//	    codul de ieșire este 1 dacă salvarea a eșuat
+func shutdownOnSignal(lobby *Lobby, dir string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	sig := <-signals
+	log.Printf("Received %v, shutting down", sig)
+
+	status := 0
+	if dir != "" {
+		if err := lobby.SaveSnapshots(dir); err != nil {
+			log.Printf("Saving snapshots: %v", err)
+			status = 1
+		}
+	}
+	lobby.Close()
+	os.Exit(status)
 }
 
-// handleLook servește request-uri GET /look/{playerID}
+// handleLook servește request-uri GET /look/{playerID}[?scores][&controllers][&pending]
 // Returnează starea curentă a tablei pentru un jucător
 //
 // Specification:
 //
 //	HTTP Method: GET
-//	URL Pattern: /look/{playerID}
+//	URL Pattern: /look/{playerID}[?scores][&controllers][&pending]
 //	Parameters:
 //	  - playerID: identificatorul jucătorului (din URL)
+//	  - delta: opțional, versiunea pe care o are clientul; răspunsul conține doar
+//	    celulele schimbate de atunci (vezi Board.SnapshotSince)
+//	  - scores: dacă e prezent, după tablă urmează clasamentul (vezi Server.snapshot)
+//	  - controllers, pending: opționale, cer formatul extins (vezi Server.snapshot)
 //	Response:
 //	  - Status: 200 OK
-//	  - Content-Type: text/plain
-//	  - Body: output-ul lui board.FormatBoard(playerID)
+//	  - Content-Type: text/plain, sau application/json dacă Accept o cere (vezi writeBoard)
+//	  - X-Board-Version: versiunea tablei (pentru /watch/?since=)
+//	  - Body: output-ul lui s.board.FormatBoard(playerID)
+//	  - Dacă playerID nu e valid: 400 Bad Request
 //	Preconditions:
-//	  - board != nil (global)
+//	  - s.board != nil
 //	Effects:
 //	  - Citește din board (thread-safe)
 //	  - Trimite răspuns HTTP
-func handleLook(w http.ResponseWriter, r *http.Request) {
-	playerID := strings.TrimPrefix(r.URL.Path, "/look/")
-
-	w.Header().Set("Content-Type", "text/plain")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+func (s *Server) handleLook(w http.ResponseWriter, r *http.Request) {
+	params, ok := pathParams(w, r, "/look/", 1)
+	if !ok {
+		return
+	}
+	playerID := params[0]
+	delta, ok := deltaParam(w, r)
+	if !ok {
+		return
+	}
 
-	fmt.Fprint(w, board.FormatBoard(playerID))
+	writeBoard(w, r, s.snapshot(PlayerView(playerID), r, delta))
 }
 
 // handleFlip servește request-uri GET /flip/{playerID}/{row},{col}
@@ -68,136 +350,291 @@ func handleLook(w http.ResponseWriter, r *http.Request) {
 //	URL Pattern: /flip/{playerID}/{row},{col}
 //	Parameters:
 //	  - playerID: identificatorul jucătorului (din URL)
+//	  - delta: opțional, versiunea pe care o are clientul; răspunsul conține doar
+//	    celulele schimbate de atunci (vezi Board.SnapshotSince)
 //	  - row: rândul cărții (0-indexed, din URL)
 //	  - col: coloana cărții (0-indexed, din URL)
 //	Response:
 //	  - Dacă operația reușește:
 //	      - Status: 200 OK
-//	      - Content-Type: text/plain
+//	      - Content-Type: text/plain, sau application/json dacă Accept o cere (vezi writeBoard)
 //	      - Body: starea tablei după flip
-//	  - Dacă operația eșuează:
+//	  - Dacă operația eșuează conform regulilor:
 //	      - Status: 409 Conflict
-//	      - Body: "Cannot flip that card"
+//	      - Body: regula care a eșuat, ex: "Rule 2-B: card is controlled by a player at (0, 1)"
+//	  - Dacă jocul e pe ture și nu e rândul jucătorului:
+//	      - Status: 409 Conflict
+//	      - Body: jucătorul la rând, ex: "Not your turn: waiting for player2"
+//	  - Dacă playerID sau coordonatele nu sunt valide (sau sunt în afara tablei):
+//	      - Status: 400 Bad Request
+//	  - Dacă serverul cere sesiuni și token-ul lipsește sau nu e al lui playerID:
+//	      - Status: 401 Unauthorized sau 403 Forbidden (vezi Server.authorize)
+//	  - Dacă playerID este spectator (vezi /spectate/):
+//	      - Status: 403 Forbidden
+//	  - Dacă jocul este șters cât timp flip-ul așteaptă cartea:
+//	      - Status: 404 Not Found
 //	Preconditions:
-//	  - board != nil (global)
-//	  - 0 <= row < board.Rows
-//	  - 0 <= col < board.Cols
+//	  - s.board != nil
 //	Postconditions:
+//	  - Dacă cartea e controlată de alt jucător, request-ul așteaptă (regula 1-D)
+//	    până când cartea este eliberată sau eliminată, sau clientul se deconectează
 //	  - Dacă reușește:
-//	      - board.version este incrementat
+//	      - s.board.version este incrementat
 //	      - Toți listeners sunt notificați
 //	      - Cartea și playerState sunt modificate conform regulilor
 //	Effects:
-//	  - Modifică board.Cards (thread-safe cu board.mu)
-//	  - Incrementează board.version
+//	  - Modifică s.board.Cards prin FlipCard (thread-safe cu s.board.mu)
+//	  - Incrementează s.board.version
 //	  - Notifică listeners
 //	  - Trimite răspuns HTTP
-func handleFlip(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleFlip(w http.ResponseWriter, r *http.Request) {
 	// Parse URL: /flip/player1/0,1
-	path := strings.TrimPrefix(r.URL.Path, "/flip/")
-	parts := strings.Split(path, "/")
-	playerID := parts[0]
-	coords := strings.Split(parts[1], ",")
-	row, _ := strconv.Atoi(coords[0])
-	col, _ := strconv.Atoi(coords[1])
-
-	playerState := board.GetPlayerState(playerID)
-
-	// Lock pentru scriere (exclusiv)
-	board.mu.Lock()
-	defer board.mu.Unlock()
-
-	var success bool
-	card := &board.Cards[row][col]
-
-	if !playerState.HasFirst {
-		// Curăță tura anterioară înainte de a începe una nouă
-		CleanupPreviousPlay(board, playerState, playerID)
-		// Încearcă să întoarcă prima carte
-		success = FlipFirstCard(board, card, row, col, playerID, playerState)
-	} else {
-		// Încearcă să întoarcă a doua carte
-		success = FlipSecondCard(board, card, row, col, playerID, playerState)
+	params, ok := pathParams(w, r, "/flip/", 2)
+	if !ok {
+		return
+	}
+	playerID := params[0]
+	delta, ok := deltaParam(w, r)
+	if !ok {
+		return
+	}
+	row, col, ok := parsePosition(params[1])
+	if !ok || !s.board.InBounds(row, col) {
+		writeError(w, http.StatusBadRequest, "Invalid position "+params[1])
+		return
+	}
+	if !s.authorize(w, r, playerID) {
+		return
 	}
 
-	if !success {
-		// Operația a eșuat
-		http.Error(w, "Cannot flip that card", http.StatusConflict)
+	if err := FlipCard(r.Context(), s.board, row, col, playerID); err != nil {
+		var ruleErr *RuleError
+		var turnErr *TurnError
+		var spectatorErr *SpectatorError
+		if errors.As(err, &ruleErr) || errors.As(err, &turnErr) {
+			// Operația a eșuat conform regulilor sau nu e rândul jucătorului
+			writeError(w, http.StatusConflict, err.Error())
+		} else if errors.As(err, &spectatorErr) {
+			writeError(w, http.StatusForbidden, err.Error())
+		} else if errors.Is(err, ErrGameDeleted) {
+			writeError(w, http.StatusNotFound, "Game deleted while waiting for the card")
+		}
+		// Altfel clientul s-a deconectat cât timp așteptam cartea
 		return
 	}
 
-	// Incrementează versiunea și notifică listeners
-	board.version++
-	board.NotifyListeners()
+	writeBoard(w, r, s.snapshot(PlayerView(playerID), r, delta))
+}
 
-	w.Header().Set("Content-Type", "text/plain")
+// handleJoin servește request-uri GET /join/{playerID}
+// Adaugă jucătorul în joc; în modul pe ture îi stabilește locul în ordinea turelor
+//
+// Specification:
+//
+//	HTTP Method: GET
+//	URL Pattern: /join/{playerID}
+//	Parameters:
+//	  - playerID: identificatorul jucătorului (din URL)
+//	  - token: opțional, token-ul curent al jucătorului, pentru reînnoire
+//	    (header Authorization: Bearer sau ?token=)
+//	Response:
+//	  - Status: 200 OK, cu tabla ca la /look/ (inclusiv linia "turn" în modul pe ture)
+//	  - Dacă serverul cere sesiuni:
+//	      - X-Session-Token: token-ul jucătorului, pentru flip, replace și map
+//	      - X-Session-Expires: momentul expirării token-ului (RFC 3339)
+//	  - Dacă playerID nu e valid: 400 Bad Request
+//	  - Dacă playerID este spectator: 403 Forbidden
+//	  - Dacă playerID are deja o sesiune și token-ul ei nu e prezentat: 409 Conflict
+//	  - Dacă token-ul nu poate fi generat: 500 Internal Server Error
+//	Postconditions:
+//	  - Token-ul este emis înainte de Board.Join: un request refuzat cu 409 nu
+//	    modifică tabla, iar dacă Join refuză un spectator token-ul nou este revocat
+//	  - Vezi Board.Join; un jucător care a intrat deja nu își schimbă locul
+//	  - Vezi Sessions.Issue; la reînnoire, token-ul vechi este revocat
+func (s *Server) handleJoin(w http.ResponseWriter, r *http.Request) {
+	params, ok := pathParams(w, r, "/join/", 1)
+	if !ok {
+		return
+	}
+	playerID := params[0]
+
+	// Sesiunea se emite înainte de Join, ca un request refuzat să nu modifice tabla
+	var token string
+	var expires time.Time
+	if s.sessions != nil {
+		var err error
+		token, expires, err = s.sessions.Issue(playerID, requestToken(r))
+		if errors.Is(err, ErrPlayerTaken) {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Player %s already joined: %v", playerID, err))
+			return
+		}
+		if err != nil {
+			log.Printf("Join %s: %v", playerID, err)
+			writeError(w, http.StatusInternalServerError, "Cannot issue a session token")
+			return
+		}
+	}
+
+	if err := s.board.Join(playerID); err != nil {
+		if token != "" {
+			s.sessions.Revoke(token)
+		}
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if token != "" {
+		w.Header().Add("Access-Control-Expose-Headers", "X-Session-Token, X-Session-Expires")
+		w.Header().Set("X-Session-Token", token)
+		w.Header().Set("X-Session-Expires", expires.UTC().Format(time.RFC3339))
+	}
+
+	writeBoard(w, r, s.snapshot(PlayerView(playerID), r, -1))
+}
+
+// handleLogout servește request-uri GET /logout/{playerID}
+// Revocă token-ul de sesiune al jucătorului, ca playerID să poată fi folosit din nou
+//
+// Specification:
+//
+//	HTTP Method: GET
+//	URL Pattern: /logout/{playerID}
+//	Parameters:
+//	  - playerID: identificatorul jucătorului (din URL)
+//	  - token: token-ul jucătorului (header Authorization: Bearer sau ?token=)
+//	Response:
+//	  - Status: 204 No Content dacă token-ul a fost revocat
+//	  - Dacă playerID nu e valid: 400 Bad Request
+//	  - Dacă serverul nu cere sesiuni: 404 Not Found
+//	  - Dacă token-ul lipsește sau nu e al lui playerID: 401 sau 403 (vezi Server.authorize)
+//	Postconditions:
+//	  - Vezi Sessions.Revoke; cărțile jucătorului rămân neschimbate
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	params, ok := pathParams(w, r, "/logout/", 1)
+	if !ok {
+		return
+	}
+	playerID := params[0]
+	if s.sessions == nil {
+		writeError(w, http.StatusNotFound, "Sessions are not enabled")
+		return
+	}
+	if !s.authorize(w, r, playerID) {
+		return
+	}
+
+	if err := s.sessions.Revoke(requestToken(r)); err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	fmt.Fprint(w, board.FormatBoard(playerID))
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// handleWatch servește request-uri GET /watch/{playerID}
-// Long polling - blochează până când tabla se modifică
+// handleWatch servește request-uri GET /watch/{playerID}[?since={version}][&scores]
+// Long polling - blochează până când tabla se modifică vizibil
 //
 // Specification:
 //
 //	HTTP Method: GET
-//	URL Pattern: /watch/{playerID}
+//	URL Pattern: /watch/{playerID}[?since={version}]
 //	Parameters:
 //	  - playerID: identificatorul jucătorului (din URL)
+//	  - delta: opțional, versiunea pe care o are clientul; răspunsul conține doar
+//	    celulele schimbate de atunci (vezi Board.SnapshotSince)
+//	  - since: ultima versiune văzută de client (opțional, implicit versiunea curentă)
+//	  - scores: dacă e prezent, după tablă urmează clasamentul (vezi Server.snapshot)
+//	  - controllers, pending: opționale, cer formatul extins (vezi Server.snapshot)
 //	Response:
-//	  - Status: 200 OK
-//	  - Content-Type: text/plain
-//	  - Body: starea tablei după modificare
+//	  - Dacă tabla s-a schimbat după since:
+//	      - Status: 200 OK
+//	      - Content-Type: text/plain, sau application/json dacă Accept o cere (vezi writeBoard)
+//	      - X-Board-Version: versiunea nouă
+//	      - Body: starea tablei după modificare
+//	  - Dacă expiră timeout-ul fără nicio schimbare:
+//	      - Status: 204 No Content
+//	      - X-Board-Version: versiunea curentă (neschimbată)
+//	  - Dacă playerID nu e valid sau since nu e un număr >= 0:
+//	      - Status: 400 Bad Request
+//	  - Dacă jocul este șters cât timp watch-ul așteaptă:
+//	      - Status: 404 Not Found
 //	Preconditions:
-//	  - board != nil (global)
+//	  - s.board != nil
 //	Postconditions:
 //	  - Funcția blochează până când:
-//	      1. Tabla se modifică (primește notificare), SAU
-//	      2. Timeout de 30 secunde, SAU
+//	      1. O carte se întoarce, e eliminată sau își schimbă valoarea, SAU
+//	      2. Timeout de s.watchTimeout, SAU
 //	      3. Clientul se deconectează
+//	  - Schimbările doar de control nu trezesc watch-ul
+//	  - Cu since, schimbările dintre două request-uri nu se pierd
 //	Effects:
-//	  - Adaugă canal la board.listeners (thread-safe)
-//	  - Șterge canal din board.listeners la final
+//	  - Adaugă canal la s.board.listeners (prin s.board.WaitForChange)
 //	  - Blochează thread-ul curent
 //	  - Citește din board
 //	  - Trimite răspuns HTTP
-func handleWatch(w http.ResponseWriter, r *http.Request) {
-	playerID := strings.TrimPrefix(r.URL.Path, "/watch/")
-
-	// Creează un canal pentru notificări
-	ch := make(chan struct{}, 1)
-
-	// Adaugă canalul la listeners
-	board.listenersMu.Lock()
-	board.listeners[ch] = true
-	board.listenersMu.Unlock()
-
-	// La final, șterge canalul din listeners
-	defer func() {
-		board.listenersMu.Lock()
-		delete(board.listeners, ch)
-		board.listenersMu.Unlock()
-		close(ch)
-	}()
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request) {
+	params, ok := pathParams(w, r, "/watch/", 1)
+	if !ok {
+		return
+	}
+	playerID := params[0]
+	delta, ok := deltaParam(w, r)
+	if !ok {
+		return
+	}
+
+	s.watch(w, r, PlayerView(playerID), delta)
+}
+
+// watch implementează long polling-ul lui /watch/ și /spectate/watch/ pentru viewer
+//
+// Specification:
+//
+//	Parameters:
+//	  - viewer: cine privește tabla (vezi Server.snapshot)
+//	  - delta: ca la handleWatch, sau -1
+//	Effects:
+//	  - Vezi handleWatch: răspunde 200 cu tabla după o schimbare vizibilă, 204 la
+//	    timeout, 400 dacă since nu e valid, 404 dacă jocul a fost șters, sau nimic
+//	    dacă clientul s-a deconectat
+func (s *Server) watch(w http.ResponseWriter, r *http.Request, viewer Viewer, delta int) {
+	since := s.board.Version()
+	if delta >= 0 {
+		since = delta
+	}
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = strconv.Atoi(value); err != nil || since < 0 {
+			writeError(w, http.StatusBadRequest, "Invalid since version "+value)
+			return
+		}
+	}
 
 	// Așteaptă până când:
-	// 1. Tabla se schimbă (primește semnal pe canal)
-	// 2. Timeout de 30 secunde
+	// 1. Tabla se schimbă vizibil după since
+	// 2. Timeout
 	// 3. Clientul se deconectează
-	select {
-	case <-ch:
-		// Tabla s-a schimbat
-	case <-time.After(30 * time.Second):
-		// Timeout
-	case <-r.Context().Done():
-		// Client deconectat
+	ctx, cancel := context.WithTimeout(r.Context(), s.watchTimeout)
+	defer cancel()
+
+	if _, err := s.board.WaitForChange(ctx, since); err != nil {
+		if r.Context().Err() != nil {
+			// Client deconectat
+			return
+		}
+		if errors.Is(err, ErrGameDeleted) {
+			writeError(w, http.StatusNotFound, "Game deleted")
+			return
+		}
+		// Timeout: nicio schimbare, clientul reia cu aceeași versiune
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Board-Version")
+		w.Header().Set("X-Board-Version", strconv.Itoa(since))
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
 	// Returnează starea actualizată
-	w.Header().Set("Content-Type", "text/plain")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	fmt.Fprint(w, board.FormatBoard(playerID))
+	writeBoard(w, r, s.snapshot(viewer, r, delta))
 }
 
 // handleReplace servește request-uri GET /replace/{playerID}/{from}/{to}
@@ -209,45 +646,716 @@ func handleWatch(w http.ResponseWriter, r *http.Request) {
 //	URL Pattern: /replace/{playerID}/{from}/{to}
 //	Parameters:
 //	  - playerID: identificatorul jucătorului (din URL)
+//	  - delta: opțional, versiunea pe care o are clientul; răspunsul conține doar
+//	    celulele schimbate de atunci (vezi Board.SnapshotSince)
 //	  - from: valoarea de înlocuit (din URL)
 //	  - to: noua valoare (din URL)
 //	Response:
 //	  - Status: 200 OK
-//	  - Content-Type: text/plain
+//	  - Content-Type: text/plain, sau application/json dacă Accept o cere (vezi writeBoard)
 //	  - Body: starea tablei după înlocuire
+//	  - Dacă playerID, from sau to nu sunt valide: 400 Bad Request
+//	  - Dacă serverul cere sesiuni și token-ul lipsește sau nu e al lui playerID:
+//	    401 Unauthorized sau 403 Forbidden (vezi Server.authorize)
+//	  - Dacă playerID este spectator: 403 Forbidden
 //	Preconditions:
-//	  - board != nil (global)
+//	  - s.board != nil
 //	Postconditions:
-//	  - Dacă cel puțin o carte a fost înlocuită:
-//	      - board.version este incrementat
+//	  - Dacă cel puțin o carte și-a schimbat valoarea:
+//	      - s.board.version este incrementat
 //	      - Toți listeners sunt notificați
 //	Effects:
-//	  - Poate modifica board.Cards (thread-safe cu board.mu)
-//	  - Poate incrementa board.version
+//	  - Poate modifica s.board.Cards prin Replace (thread-safe cu s.board.mu)
+//	  - Poate incrementa s.board.version
 //	  - Poate notifica listeners
 //	  - Trimite răspuns HTTP
-func handleReplace(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleReplace(w http.ResponseWriter, r *http.Request) {
 	// Parse URL: /replace/player1/A/B
-	path := strings.TrimPrefix(r.URL.Path, "/replace/")
-	parts := strings.Split(path, "/")
-	playerID := parts[0]
-	fromCard := parts[1]
-	toCard := parts[2]
+	params, ok := pathParams(w, r, "/replace/", 3)
+	if !ok {
+		return
+	}
+	playerID, fromCard, toCard := params[0], params[1], params[2]
+	delta, ok := deltaParam(w, r)
+	if !ok {
+		return
+	}
+	if !IsValidCardValue(fromCard) || !IsValidCardValue(toCard) {
+		writeError(w, http.StatusBadRequest, "Invalid card value")
+		return
+	}
+	if !s.authorize(w, r, playerID) {
+		return
+	}
+
+	// Versiunea crește doar dacă s-a schimbat vreo valoare
+	if _, err := Replace(s.board, playerID, fromCard, toCard); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	writeBoard(w, r, s.snapshot(PlayerView(playerID), r, delta))
+}
+
+// handleMap servește request-uri GET /map/{playerID}?{from}={to}&...
+// Înlocuiește fiecare carte de pe tablă conform tabelei de substituție din query
+//
+// Specification:
+//
+//	HTTP Method: GET
+//	URL Pattern: /map/{playerID}?{from}={to}&{from}={to}...
+//	Parameters:
+//	  - playerID: identificatorul jucătorului (din URL)
+//	  - from=to: perechi de valori din query; cărțile fără pereche rămân neschimbate;
+//	    dacă serverul cere sesiuni, cheia "token" este token-ul, nu o pereche
+//	    (o carte "token" se poate înlocui trimițând token-ul în header-ul Authorization)
+//	Response:
+//	  - Dacă operația reușește:
+//	      - Status: 200 OK
+//	      - Content-Type: text/plain, sau application/json dacă Accept o cere (vezi writeBoard)
+//	      - Body: starea tablei după map
+//	  - Dacă playerID sau o valoare din query nu e validă:
+//	      - Status: 400 Bad Request
+//	  - Dacă serverul cere sesiuni și token-ul lipsește sau nu e al lui playerID:
+//	      - Status: 401 Unauthorized sau 403 Forbidden (vezi Server.authorize)
+//	  - Dacă playerID este spectator:
+//	      - Status: 403 Forbidden
+//	Preconditions:
+//	  - s.board != nil
+//	Postconditions:
+//	  - Vezi Board.Map: look și flip nu sunt blocate, perechile rămân consistente
+//	Effects:
+//	  - Modifică s.board.Cards prin s.board.Map
+//	  - Trimite răspuns HTTP
+func (s *Server) handleMap(w http.ResponseWriter, r *http.Request) {
+	params, ok := pathParams(w, r, "/map/", 1)
+	if !ok {
+		return
+	}
+	playerID := params[0]
+	if !s.authorize(w, r, playerID) {
+		return
+	}
+	tokenInQuery := s.sessions != nil && r.Header.Get("Authorization") == ""
+
+	// Construiește tabela de substituție: ?A=B&C=D
+	substitutions := make(map[string]string)
+	for from, to := range r.URL.Query() {
+		if from == "token" && tokenInQuery {
+			continue
+		}
+		if !IsValidCardValue(from) || !IsValidCardValue(to[len(to)-1]) {
+			writeError(w, http.StatusBadRequest, "Invalid card value")
+			return
+		}
+		substitutions[from] = to[len(to)-1]
+	}
+
+	_, err := s.board.Map(playerID, func(card string) (string, error) {
+		if to, ok := substitutions[card]; ok {
+			return to, nil
+		}
+		return card, nil
+	})
+	if err != nil {
+		var spectatorErr *SpectatorError
+		if errors.As(err, &spectatorErr) {
+			writeError(w, http.StatusForbidden, err.Error())
+		} else {
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	writeBoard(w, r, s.snapshot(PlayerView(playerID), r, -1))
+}
 
-	// Lock pentru scriere
-	board.mu.Lock()
-	replaced := ReplaceCards(board, playerID, fromCard, toCard)
-	if replaced {
-		board.version++
+// writeBoard trimite starea tablei ca text sau JSON, împreună cu versiunea ei
+//
+// Specification:
+//
+//	Parameters:
+//	  - w: writer-ul răspunsului HTTP
+//	  - r: request-ul, pentru negocierea conținutului (wantsJSON)
+//	  - snapshot: tabla de trimis
+//	Effects:
+//	  - Setează Content-Type (text/plain sau application/json), Vary, CORS și X-Board-Version
+//	  - Scrie snapshot.Text() sau snapshot ca JSON în corpul răspunsului (status 200)
+func writeBoard(w http.ResponseWriter, r *http.Request, snapshot BoardSnapshot) {
+	w.Header().Set("Vary", "Accept")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Access-Control-Expose-Headers", "X-Board-Version")
+	w.Header().Set("X-Board-Version", strconv.Itoa(snapshot.Version))
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(snapshot)
+		return
 	}
-	board.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, snapshot.Text())
+}
 
-	// Notifică listeners dacă s-au făcut schimbări
-	if replaced {
-		board.NotifyListeners()
+// handleScores servește request-uri GET /scores/
+// Returnează clasamentul jucătorilor
+//
+// Specification:
+//
+//	HTTP Method: GET
+//	URL Pattern: /scores/
+//	Response:
+//	  - Status: 200 OK
+//	  - Content-Type: text/plain
+//	  - Body: FormatScores(s.board.Scores(), ""), câte un jucător pe linie:
+//	      "{playerID} {pairs} {failedFlips} {lastAction}"
+//	  - Dacă după /scores/ mai urmează ceva: 404 Not Found
+//	Preconditions:
+//	  - s.board != nil
+//	Effects:
+//	  - Citește din board (thread-safe)
+//	  - Trimite răspuns HTTP
+func (s *Server) handleScores(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/scores/" {
+		writeError(w, http.StatusNotFound, "Unknown route "+r.URL.Path)
+		return
 	}
 
 	w.Header().Set("Content-Type", "text/plain")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	fmt.Fprint(w, board.FormatBoard(playerID))
+	fmt.Fprint(w, FormatScores(s.board.Scores(), ""))
+}
+
+// handleEvents servește stream-uri Server-Sent Events pe /events/{playerID}[?scores]
+// Alternativă la long polling pentru clienții care nu pot folosi WebSocket
+//
+// Specification:
+//
+//	HTTP Method: GET
+//	URL Pattern: /events/{playerID}[?scores]
+//	Parameters:
+//	  - playerID: identificatorul jucătorului (din URL)
+//	  - Last-Event-ID (header, opțional): ultima versiune primită de client;
+//	    setat automat de EventSource la reconectare
+//	  - scores: dacă e prezent, fiecare tablă e urmată de clasament
+//	Response:
+//	  - Status: 200 OK, Content-Type: text/event-stream
+//	  - Un eveniment "board" pentru fiecare schimbare vizibilă, cu
+//	    "id: {version}" și tabla jucătorului pe linii "data: "
+//	  - Primul eveniment e trimis imediat, dacă nu există Last-Event-ID sau tabla
+//	    s-a schimbat după el; altfel doar la următoarea schimbare
+//	  - Un comentariu ": keep-alive" după fiecare s.watchTimeout fără schimbări
+//	  - Dacă playerID sau Last-Event-ID nu sunt valide: 400 Bad Request
+//	Postconditions:
+//	  - La deconectare, canalul din s.board.listeners este eliminat
+//	    (prin s.board.WaitForChange, ca la handleWatch)
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	params, ok := pathParams(w, r, "/events/", 1)
+	if !ok {
+		return
+	}
+	playerID := params[0]
+
+	s.events(w, r, PlayerView(playerID))
+}
+
+// events implementează stream-ul lui /events/ și /spectate/events/ pentru viewer
+//
+// Specification:
+//
+//	Parameters:
+//	  - viewer: cine privește tabla (vezi Server.snapshot)
+//	Effects:
+//	  - Vezi handleEvents: trimite tabla lui viewer la fiecare schimbare vizibilă,
+//	    până la deconectare
+func (s *Server) events(w http.ResponseWriter, r *http.Request, viewer Viewer) {
+	since := -1
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		var err error
+		if since, err = strconv.Atoi(value); err != nil || since < 0 {
+			writeError(w, http.StatusBadRequest, "Invalid Last-Event-ID "+value)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		if since < 0 || s.board.Version() > since {
+			snapshot := s.snapshot(viewer, r, -1)
+			writeEvent(w, "board", snapshot.Version, snapshot.Text())
+			since = snapshot.Version
+		} else {
+			// Așteaptă o schimbare; la timeout trimite un keep-alive, ca proxy-urile
+			// să nu închidă conexiunea și ca un client plecat să fie detectat
+			ctx, cancel := context.WithTimeout(r.Context(), s.watchTimeout)
+			_, err := s.board.WaitForChange(ctx, since)
+			cancel()
+			if r.Context().Err() != nil || errors.Is(err, ErrGameDeleted) {
+				// Client deconectat sau joc șters
+				return
+			}
+			if err != nil {
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent scrie un eveniment Server-Sent Events
+//
+// Specification:
+//
+//	Postconditions:
+//	  - Scrie "event: {name}", "id: {id}", câte o linie "data: " pentru fiecare
+//	    linie din data (fără newline-ul final) și o linie goală
+func writeEvent(w io.Writer, name string, id int, data string) {
+	fmt.Fprintf(w, "event: %s\nid: %d\n", name, id)
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+// handleSpectateLook servește request-uri GET /spectate/look/{spectatorID}[?controllers][&scores]
+// Returnează tabla văzută de un spectator
+//
+// Specification:
+//
+//	HTTP Method: GET
+//	URL Pattern: /spectate/look/{spectatorID}[?controllers]
+//	Parameters:
+//	  - spectatorID: identificatorul spectatorului (din URL)
+//	  - controllers, pending, delta, scores: ca la /look/
+//	Response:
+//	  - Ca la /look/, dar nicio carte nu apare ca "my X" (vezi SpectatorView)
+//	  - Dacă spectatorID nu e valid: 400 Bad Request
+//	  - Dacă spectatorID a jucat deja sau are o sesiune: 409 Conflict
+//	Postconditions:
+//	  - spectatorID nu rămâne spectator după răspuns: poate juca mai târziu
+func (s *Server) handleSpectateLook(w http.ResponseWriter, r *http.Request) {
+	viewer, release, ok := s.spectator(w, r, "/spectate/look/")
+	if !ok {
+		return
+	}
+	defer release()
+	delta, ok := deltaParam(w, r)
+	if !ok {
+		return
+	}
+
+	writeBoard(w, r, s.snapshot(viewer, r, delta))
+}
+
+// handleSpectateWatch servește request-uri GET /spectate/watch/{spectatorID}[?since={version}][&controllers]
+// Long polling pentru spectatori
+//
+// Specification:
+//
+//	HTTP Method: GET
+//	URL Pattern: /spectate/watch/{spectatorID}[?since={version}][&controllers]
+//	Response:
+//	  - Ca la /watch/, cu tabla văzută de spectator (vezi handleSpectateLook)
+//	  - Dacă spectatorID a jucat deja sau are o sesiune: 409 Conflict
+//	Postconditions:
+//	  - Cât timp request-ul așteaptă, flip, replace, map și join refuză
+//	    spectatorID cu 403 Forbidden (vezi Board.Spectate)
+func (s *Server) handleSpectateWatch(w http.ResponseWriter, r *http.Request) {
+	viewer, release, ok := s.spectator(w, r, "/spectate/watch/")
+	if !ok {
+		return
+	}
+	defer release()
+	delta, ok := deltaParam(w, r)
+	if !ok {
+		return
+	}
+
+	s.watch(w, r, viewer, delta)
+}
+
+// handleSpectateEvents servește stream-uri Server-Sent Events pe /spectate/events/{spectatorID}[?controllers]
+//
+// Specification:
+//
+//	HTTP Method: GET
+//	URL Pattern: /spectate/events/{spectatorID}[?controllers][&scores]
+//	Response:
+//	  - Ca la /events/, cu tabla văzută de spectator (vezi handleSpectateLook)
+//	  - Dacă spectatorID a jucat deja sau are o sesiune: 409 Conflict
+//	Postconditions:
+//	  - Cât timp stream-ul e deschis, flip, replace, map și join refuză
+//	    spectatorID cu 403 Forbidden (vezi Board.Spectate)
+func (s *Server) handleSpectateEvents(w http.ResponseWriter, r *http.Request) {
+	viewer, release, ok := s.spectator(w, r, "/spectate/events/")
+	if !ok {
+		return
+	}
+	defer release()
+
+	s.events(w, r, viewer)
+}
+
+// spectator înregistrează spectatorul din URL și returnează vederea lui
+//
+// Specification:
+//
+//	Parameters:
+//	  - prefix: prefixul rutei, ex: "/spectate/look/"
+//	Returns:
+//	  - Viewer: SpectatorView(spectatorID, false), dacă ok; formatul extins se cere
+//	    din query, ca pentru jucători (vezi Server.snapshot)
+//	  - func(): încheie înregistrarea (vezi Board.Spectate); handler-ul o apelează
+//	    când termină request-ul
+//	  - bool: false dacă spectatorID nu e valid (400), a jucat deja sau are o
+//	          sesiune (409); în acest caz răspunsul a fost deja trimis
+func (s *Server) spectator(w http.ResponseWriter, r *http.Request, prefix string) (Viewer, func(), bool) {
+	params, ok := pathParams(w, r, prefix, 1)
+	if !ok {
+		return Viewer{}, nil, false
+	}
+	spectatorID := params[0]
+	if s.sessions != nil && s.sessions.Active(spectatorID) {
+		// Un spectator nu poate bloca jucătorul care deține sesiunea acestui ID
+		writeError(w, http.StatusConflict, spectatorID+" has a session and cannot spectate")
+		return Viewer{}, nil, false
+	}
+	release, err := s.board.Spectate(spectatorID)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return Viewer{}, nil, false
+	}
+	return SpectatorView(spectatorID, false), release, true
+}
+
+// maxQueuedCommands este numărul maxim de comenzi primite pe o conexiune
+// WebSocket sau TCP care așteaptă să fie executate; un client care trimite mai
+// multe este deconectat
+const maxQueuedCommands = 64
+
+// handleWebSocket servește conexiuni WebSocket pe /ws/{playerID}[?scores]
+// Trimite tabla la fiecare schimbare și execută comenzile primite pe aceeași conexiune
+//
+// Specification:
+//
+//	HTTP Method: GET, cu handshake WebSocket (RFC 6455)
+//	URL Pattern: /ws/{playerID}[?scores]
+//	Parameters:
+//	  - playerID: identificatorul jucătorului (din URL)
+//	  - scores: dacă e prezent, fiecare tablă trimisă e urmată de clasament
+//	  - token: dacă serverul cere sesiuni, token-ul jucătorului (?token=), necesar
+//	    pentru flip și replace; fără el conexiunea primește doar tabla
+//	Mesaje de la client (text):
+//	  - "look": cere tabla curentă
+//	  - "flip {row},{col}": ca /flip/; poate aștepta (regula 1-D)
+//	  - "replace {from} {to}": ca /replace/
+//	Mesaje către client (text):
+//	  - Tabla, în formatul lui FormatBoard: la conectare și după fiecare schimbare vizibilă
+//	  - "ok {comanda}" pe prima linie, urmată de tablă: răspunsul la o comandă reușită
+//	  - "error {mesaj}" dacă o comandă eșuează (ex: "error Rule 2-B: ...")
+//	Response:
+//	  - 101 Switching Protocols dacă handshake-ul e valid
+//	  - 400 Bad Request dacă playerID nu e valid sau request-ul nu e un handshake
+//	  - 426 Upgrade Required dacă versiunea WebSocket nu e 13
+//	Postconditions:
+//	  - Comenzile sunt executate în ordinea primirii; cel mult maxQueuedCommands
+//	    așteaptă în coadă, altfel conexiunea este închisă fără să mai execute
+//	    comenzile din coadă
+//	  - Conexiunea este citită tot timpul, chiar dacă un flip așteaptă, deci la
+//	    deconectare flip-ul care așteaptă este anulat (nu ia cartea pentru un
+//	    jucător plecat), iar canalul din s.board.listeners este eliminat
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	params, ok := pathParams(w, r, "/ws/", 1)
+	if !ok {
+		return
+	}
+	playerID := params[0]
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// ctx este anulat când clientul pleacă sau o scriere eșuează
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	commands := make(chan string, maxQueuedCommands)
+
+	// Trimite tabla la conectare și după fiecare schimbare vizibilă
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		snapshot := s.snapshot(PlayerView(playerID), r, -1)
+		for conn.WriteText(snapshot.Text()) == nil {
+			if _, err := s.board.WaitForChange(ctx, snapshot.Version); err != nil {
+				return
+			}
+			snapshot = s.snapshot(PlayerView(playerID), r, -1)
+		}
+	}()
+
+	// Execută comenzile una câte una; un flip care așteaptă nu blochează citirea,
+	// pentru că mesajele noi așteaptă în coada commands
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		for command := range commands {
+			if ctx.Err() != nil {
+				// Conexiunea se închide: comenzile rămase în coadă nu mai sunt executate
+				return
+			}
+			reply := s.runWebSocketCommand(ctx, playerID, command, r)
+			if reply != "" && conn.WriteText(reply) != nil {
+				return
+			}
+		}
+	}()
+
+	for ctx.Err() == nil {
+		message, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		select {
+		case commands <- message:
+		default:
+			log.Printf("WebSocket for %s: more than %d queued commands, closing", playerID, maxQueuedCommands)
+			cancel()
+		}
+	}
+	close(commands)
+	cancel()
+	wg.Wait()
+}
+
+// runWebSocketCommand execută o comandă primită pe /ws/ și returnează răspunsul
+//
+// Specification:
+//
+//	Returns:
+//	  - string: "ok {comanda}" și tabla după comandă, "error {mesaj}" dacă comanda
+//	    e invalidă, token-ul de sesiune nu e (mai) valid sau flip-ul a eșuat, sau ""
+//	    dacă ctx a fost anulat în timpul așteptării
+func (s *Server) runWebSocketCommand(ctx context.Context, playerID, command string, r *http.Request) string {
+	fields := strings.Fields(command)
+	switch {
+	case len(fields) == 1 && fields[0] == "look":
+	case len(fields) == 2 && fields[0] == "flip":
+		row, col, ok := parsePosition(fields[1])
+		if !ok || !s.board.InBounds(row, col) {
+			return "error Invalid position " + fields[1]
+		}
+		if status, message := authorize(s.sessions, requestToken(r), playerID); status != 0 {
+			return "error " + message
+		}
+		if err := FlipCard(ctx, s.board, row, col, playerID); err != nil {
+			var ruleErr *RuleError
+			var turnErr *TurnError
+			var spectatorErr *SpectatorError
+			if errors.As(err, &ruleErr) || errors.As(err, &turnErr) || errors.As(err, &spectatorErr) || errors.Is(err, ErrGameDeleted) {
+				return "error " + err.Error()
+			}
+			return ""
+		}
+	case len(fields) == 3 && fields[0] == "replace":
+		if !IsValidCardValue(fields[1]) || !IsValidCardValue(fields[2]) {
+			return "error Invalid card value"
+		}
+		if status, message := authorize(s.sessions, requestToken(r), playerID); status != 0 {
+			return "error " + message
+		}
+		if _, err := Replace(s.board, playerID, fields[1], fields[2]); err != nil {
+			return "error " + err.Error()
+		}
+	default:
+		return "error Unknown command " + strconv.Quote(command)
+	}
+
+	return "ok " + strings.Join(fields, " ") + "\n" + s.snapshot(PlayerView(playerID), r, -1).Text()
+}
+
+// snapshot returnează tabla văzută de viewer, cu clasamentul dacă request-ul îl cere
+//
+// Specification:
+//
+//	Parameters:
+//	  - viewer: jucătorul (PlayerView) sau spectatorul care privește tabla
+//	  - delta: versiunea pe care o are clientul (vezi deltaParam), sau -1 pentru toată tabla
+//	Returns:
+//	  - BoardSnapshot: s.board.SnapshotAs(viewer, delta), în formatul extins dacă
+//	    query-ul îl cere: cu "controllers", cărțile controlate de alții apar ca
+//	    "up X by {player}", iar cu "pending", turele începute apar pe linii
+//	    "pending {player} {row},{col} ..." (vezi Viewer)
+//	  - Dacă query-ul conține "scores", Scores este s.board.Scores(), iar în text
+//	    clasamentul apare după tablă pe linii "score {playerID} ...", care nu pot
+//	    fi confundate cu liniile tablei
+func (s *Server) snapshot(viewer Viewer, r *http.Request, delta int) BoardSnapshot {
+	query := r.URL.Query()
+	viewer.ShowControllers = viewer.ShowControllers || query.Has("controllers")
+	viewer.ShowPending = viewer.ShowPending || query.Has("pending")
+	snapshot := s.board.SnapshotAs(viewer, delta)
+	if query.Has("scores") {
+		snapshot.Scores = s.board.Scores()
+	}
+	return snapshot
+}
+
+// deltaParam citește parametrul opțional delta={version} din query
+//
+// Specification:
+//
+//	Returns:
+//	  - int: versiunea din query, sau -1 dacă parametrul lipsește
+//	  - bool: false dacă valoarea nu e un număr >= 0; în acest caz s-a trimis deja 400
+func deltaParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("delta")
+	if value == "" {
+		return -1, true
+	}
+	delta, err := strconv.Atoi(value)
+	if err != nil || delta < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid delta version "+value)
+		return 0, false
+	}
+	return delta, true
+}
+
+// wantsJSON verifică dacă request-ul preferă JSON în locul formatului text
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă Accept conține application/json cu o calitate (q) mai mare
+//	    decât text/plain; fără header Accept, răspunsul rămâne text
+func wantsJSON(r *http.Request) bool {
+	jsonQuality, textQuality := 0.0, 0.0
+	for _, value := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			quality := 1.0
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+				quality = q
+			}
+			switch mediaType {
+			case "application/json":
+				jsonQuality = max(jsonQuality, quality)
+			case "text/plain", "text/*":
+				textQuality = max(textQuality, quality)
+			}
+		}
+	}
+	return jsonQuality > 0 && jsonQuality > textQuality
+}
+
+// writeError trimite un răspuns de eroare text, cu header-ele CORS
+//
+// Specification:
+//
+//	Parameters:
+//	  - w: writer-ul răspunsului HTTP
+//	  - status: codul HTTP (400, 401, 403, 404, 405 sau 409)
+//	  - message: mesajul din corpul răspunsului
+//	Effects:
+//	  - Setează CORS, pentru ca și clienții de pe alt origin să citească mesajul
+//	  - Scrie message în corpul răspunsului
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	http.Error(w, message, status)
+}
+
+// authorize verifică token-ul de sesiune al request-ului pentru playerID
+//
+// Specification:
+//
+//	Returns:
+//	  - bool: true dacă serverul nu cere sesiuni sau token-ul e valid și al lui
+//	    playerID; altfel s-a trimis deja 401 Unauthorized (token lipsă, invalid,
+//	    expirat sau revocat) sau 403 Forbidden (token-ul altui jucător)
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, playerID string) bool {
+	if status, message := authorize(s.sessions, requestToken(r), playerID); status != 0 {
+		writeError(w, status, message)
+		return false
+	}
+	return true
+}
+
+// getOnly acceptă doar request-uri GET pentru handler-ul dat
+//
+// Specification:
+//
+//	Returns:
+//	  - http.HandlerFunc care răspunde 405 Method Not Allowed (cu Allow: GET)
+//	    pentru orice altă metodă, altfel apelează handler
+func getOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, "Method "+r.Method+" not allowed")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// pathParams împarte calea de după prefix în exact n segmente și validează playerID
+//
+// Specification:
+//
+//	Parameters:
+//	  - prefix: prefixul rutei, ex: "/flip/"
+//	  - n: numărul de segmente așteptat; primul segment este playerID
+//	Returns:
+//	  - []string: cele n segmente, dacă ok
+//	  - bool: false dacă numărul de segmente e greșit sau playerID nu e valid;
+//	          în acest caz răspunsul 400 Bad Request a fost deja trimis
+func pathParams(w http.ResponseWriter, r *http.Request, prefix string, n int) ([]string, bool) {
+	params := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if len(params) != n {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Expected %d path segments after %s", n, prefix))
+		return nil, false
+	}
+	if !IsValidPlayerID(params[0]) {
+		writeError(w, http.StatusBadRequest, "Invalid player ID "+strconv.Quote(params[0]))
+		return nil, false
+	}
+	return params, true
+}
+
+// parsePosition parsează coordonatele "row,col"
+//
+// Specification:
+//
+//	Returns:
+//	  - row, col: coordonatele, dacă ok
+//	  - bool: false dacă text nu are forma "{row},{col}" cu numere întregi >= 0
+func parsePosition(text string) (int, int, bool) {
+	coords := strings.Split(text, ",")
+	if len(coords) != 2 {
+		return 0, 0, false
+	}
+	row, err := strconv.Atoi(coords[0])
+	if err != nil || row < 0 {
+		return 0, 0, false
+	}
+	col, err := strconv.Atoi(coords[1])
+	if err != nil || col < 0 {
+		return 0, 0, false
+	}
+	return row, col, true
 }
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer pornește un server HTTP de test pentru o tablă dată ca text
+func newTestServer(t *testing.T, boardText string, watchTimeout time.Duration) (*Board, *httptest.Server) {
+	t.Helper()
+	board, err := ParseBoard(strings.NewReader(boardText), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse board: %v", err)
+	}
+	server := httptest.NewServer(NewServer(board, "", watchTimeout).Handler())
+	t.Cleanup(server.Close)
+	return board, server
+}
+
+// get face un request GET și returnează statusul și corpul răspunsului
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+// Test that two servers in one process play on independent boards
+func TestServersAreIndependent(t *testing.T) {
+	_, first := newTestServer(t, "1x2\nA\nA\n", time.Second)
+	_, second := newTestServer(t, "1x2\nB\nB\n", time.Second)
+
+	status, body := get(t, first.URL+"/flip/player1/0,0")
+	if status != http.StatusOK || body != "1x2\nmy A\ndown\n" {
+		t.Errorf("Unexpected flip response %d %q", status, body)
+	}
+
+	_, body = get(t, second.URL+"/look/player1")
+	if body != "1x2\ndown\ndown\n" {
+		t.Errorf("Second server should be untouched, got %q", body)
+	}
+}
+
+// Test that watch answers 204 with the unchanged version when it times out
+func TestWatchTimeoutReturnsNoContent(t *testing.T) {
+	_, server := newTestServer(t, "1x2\nA\nA\n", 10*time.Millisecond)
+
+	resp, err := http.Get(server.URL + "/watch/player1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Board-Version") != "0" {
+		t.Errorf("Expected version 0, got %q", resp.Header.Get("X-Board-Version"))
+	}
+}
+
+// Test that watch with an old since version returns the changed board at once
+func TestWatchSinceReturnsMissedChange(t *testing.T) {
+	_, server := newTestServer(t, "1x2\nA\nA\n", time.Second)
+
+	get(t, server.URL+"/flip/player1/0,0")
+
+	resp, err := http.Get(server.URL + "/watch/player2?since=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "1x2\nup A\ndown\n" {
+		t.Errorf("Unexpected watch response %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Board-Version") != "1" {
+		t.Errorf("Expected version 1, got %q", resp.Header.Get("X-Board-Version"))
+	}
+}
+
+// Test that every route validates its input and uses the right status code
+func TestErrorStatusCodes(t *testing.T) {
+	_, server := newTestServer(t, "1x3\nA\nA\nB\n", 10*time.Millisecond)
+
+	cases := []struct {
+		path   string
+		status int
+	}{
+		{"/flip/p", http.StatusBadRequest},
+		{"/flip/p/99,99", http.StatusBadRequest},
+		{"/flip/p/0", http.StatusBadRequest},
+		{"/flip/p/-1,0", http.StatusBadRequest},
+		{"/flip/p/0,x", http.StatusBadRequest},
+		{"/flip/bad-id/0,0", http.StatusBadRequest},
+		{"/look/", http.StatusBadRequest},
+		{"/look/p/extra", http.StatusBadRequest},
+		{"/watch/p?since=abc", http.StatusBadRequest},
+		{"/replace/p/A", http.StatusBadRequest},
+		{"/map/p?A=", http.StatusBadRequest},
+		{"/unknown", http.StatusNotFound},
+		{"/look/player_1", http.StatusOK},
+	}
+	for _, c := range cases {
+		if status, body := get(t, server.URL+c.path); status != c.status {
+			t.Errorf("GET %s: expected %d, got %d %q", c.path, c.status, status, body)
+		}
+	}
+
+	resp, err := http.Post(server.URL+"/look/p", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != "GET" {
+		t.Errorf("POST: expected 405 with Allow: GET, got %d %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+}
+
+// Test that a failed flip answers 409 naming the rule that failed
+func TestFlipConflictNamesRule(t *testing.T) {
+	_, server := newTestServer(t, "1x4\nA\nA\nB\nC\n", time.Second)
+
+	get(t, server.URL+"/flip/p1/0,0")
+	get(t, server.URL+"/flip/p1/0,1")
+	get(t, server.URL+"/flip/p1/0,2") // elimină perechea A, p1 controlează B
+
+	status, body := get(t, server.URL+"/flip/p2/0,0")
+	if status != http.StatusConflict || !strings.HasPrefix(body, "Rule 1-A") {
+		t.Errorf("Expected 409 for rule 1-A, got %d %q", status, body)
+	}
+
+	get(t, server.URL+"/flip/p2/0,3")
+	status, body = get(t, server.URL+"/flip/p2/0,2")
+	if status != http.StatusConflict || !strings.HasPrefix(body, "Rule 2-B") {
+		t.Errorf("Expected 409 for rule 2-B, got %d %q", status, body)
+	}
+}
+
+// Test that /scores/ and /look/?scores report the leaderboard
+func TestScoresEndpoints(t *testing.T) {
+	_, server := newTestServer(t, "1x3\nA\nA\nB\n", time.Second)
+
+	get(t, server.URL+"/flip/p1/0,0")
+	get(t, server.URL+"/flip/p1/0,1")
+	get(t, server.URL+"/flip/p1/0,2")
+
+	_, body := get(t, server.URL+"/scores/")
+	if !strings.HasPrefix(body, "p1 1 0 ") {
+		t.Errorf("Unexpected scores %q", body)
+	}
+
+	_, body = get(t, server.URL+"/look/p2?scores")
+	if !strings.HasPrefix(body, "1x3\nnone\nnone\nup B\nscore p1 1 0 ") {
+		t.Errorf("Unexpected look with scores %q", body)
+	}
+	_, body = get(t, server.URL+"/look/p2")
+	if body != "1x3\nnone\nnone\nup B\n" {
+		t.Errorf("Look without ?scores should be unchanged, got %q", body)
+	}
+}
+
+// openEvents deschide un stream /events/ cu Last-Event-ID opțional
+func openEvents(t *testing.T, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", ct)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+// readEvent citește următorul eveniment și returnează id-ul și datele lui
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	t.Helper()
+	var id string
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && data != nil:
+			return id, strings.Join(data, "\n")
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+// Test that /events/ streams each change with its version and resumes from Last-Event-ID
+func TestEventsStreamAndResume(t *testing.T) {
+	_, server := newTestServer(t, "1x2\nA\nB\n", time.Second)
+
+	events := openEvents(t, server.URL+"/events/p1", "")
+	if id, data := readEvent(t, events); id != "0" || data != "1x2\ndown\ndown" {
+		t.Fatalf("Unexpected first event id=%s data=%q", id, data)
+	}
+
+	get(t, server.URL+"/flip/p1/0,0")
+	if id, data := readEvent(t, events); id != "1" || data != "1x2\nmy A\ndown" {
+		t.Fatalf("Unexpected change event id=%s data=%q", id, data)
+	}
+
+	// Un client care a văzut versiunea 0 primește imediat starea curentă
+	resumed := openEvents(t, server.URL+"/events/p2", "0")
+	if id, data := readEvent(t, resumed); id != "1" || data != "1x2\nup A\ndown" {
+		t.Errorf("Unexpected resumed event id=%s data=%q", id, data)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/events/p1", nil)
+	req.Header.Set("Last-Event-ID", "x")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET with invalid Last-Event-ID: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid Last-Event-ID, got %d", resp.StatusCode)
+	}
+}
+
+// Test that a stream resumed at the current version waits and sends keep-alives
+func TestEventsKeepAlive(t *testing.T) {
+	board, server := newTestServer(t, "1x2\nA\nB\n", 20*time.Millisecond)
+
+	events := openEvents(t, server.URL+"/events/p1", "0")
+	if line, _ := events.ReadString('\n'); line != ": keep-alive\n" {
+		t.Errorf("Expected keep-alive, got %q", line)
+	}
+
+	FlipCard(context.Background(), board, 0, 1, "p2")
+	if id, data := readEvent(t, events); id != "1" || data != "1x2\ndown\nup B" {
+		t.Errorf("Unexpected event id=%s data=%q", id, data)
+	}
+}
+
+// getJSON face un request GET cu Accept: application/json și decodează tabla
+func getJSON(t *testing.T, url string) BoardSnapshot {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Expected application/json, got %q", ct)
+	}
+	var snapshot BoardSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		t.Fatalf("Decoding %s: %v", url, err)
+	}
+	return snapshot
+}
+
+// parseBoardText parsează formatul text al tablei, inclusiv formatul extins
+// ("up X by {player}" și liniile "pending"), în BoardSnapshot; Version,
+// FirstCard și Scores rămân necompletate
+func parseBoardText(t *testing.T, text string) BoardSnapshot {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	var snapshot BoardSnapshot
+	if _, err := fmt.Sscanf(lines[0], "%dx%d", &snapshot.Rows, &snapshot.Cols); err != nil {
+		t.Fatalf("Bad dimensions line %q", lines[0])
+	}
+	lines = lines[1:]
+	if len(lines) > 0 && lines[0] == "delta" {
+		snapshot.Delta = true
+		lines = lines[1:]
+	}
+
+	parsePos := func(text string) Position {
+		var pos Position
+		if _, err := fmt.Sscanf(text, "%d,%d", &pos.Row, &pos.Col); err != nil {
+			t.Fatalf("Bad position %q", text)
+		}
+		return pos
+	}
+	for index, line := range lines {
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "turn":
+			snapshot.Turn = fields[1]
+			continue
+		case "pending":
+			pending := PendingTurn{Player: fields[1]}
+			for _, field := range fields[2:] {
+				pending.Cards = append(pending.Cards, parsePos(field))
+			}
+			snapshot.Pending = append(snapshot.Pending, pending)
+			continue
+		case "over":
+			snapshot.GameOver = true
+			snapshot.Winners = fields[1:]
+			continue
+		case "score":
+			continue
+		}
+
+		cell := CellView{Row: index / snapshot.Cols, Col: index % snapshot.Cols}
+		if snapshot.Delta {
+			pos := parsePos(fields[0])
+			cell.Row, cell.Col = pos.Row, pos.Col
+			fields = fields[1:]
+		}
+		switch {
+		case len(fields) == 1 && (fields[0] == "none" || fields[0] == "down"):
+			cell.State = fields[0]
+		case len(fields) == 2 && fields[0] == "my":
+			cell.State, cell.Value, cell.Mine = "up", fields[1], true
+		case len(fields) == 2 && fields[0] == "up":
+			cell.State, cell.Value = "up", fields[1]
+		case len(fields) == 4 && fields[0] == "up" && fields[2] == "by":
+			cell.State, cell.Value, cell.Controller = "up", fields[1], fields[3]
+		default:
+			t.Fatalf("Bad cell line %q", line)
+		}
+		snapshot.Cells = append(snapshot.Cells, cell)
+	}
+	return snapshot
+}
+
+// Test that the extended format names controllers and pending turns, in text and JSON
+func TestExtendedFormat(t *testing.T) {
+	_, server := newTestServer(t, "2x2\nA\nB\nA\nB\n", time.Second)
+	get(t, server.URL+"/flip/player1/0,0")
+	get(t, server.URL+"/flip/player2/0,1")
+
+	if _, body := get(t, server.URL+"/look/player1"); body != "2x2\nmy A\nup B\ndown\ndown\n" {
+		t.Errorf("The default format should stay unchanged, got %q", body)
+	}
+	_, body := get(t, server.URL+"/look/player1?controllers&pending")
+	if body != "2x2\nmy A\nup B by player2\ndown\ndown\npending player1 0,0\npending player2 0,1\n" {
+		t.Errorf("Unexpected extended board %q", body)
+	}
+
+	text := parseBoardText(t, body)
+	snapshot := getJSON(t, server.URL+"/look/player1?controllers&pending")
+	if !reflect.DeepEqual(text.Cells, snapshot.Cells) || !reflect.DeepEqual(text.Pending, snapshot.Pending) {
+		t.Errorf("Text and JSON disagree:\n%+v\n%+v", text, snapshot)
+	}
+	if cell := snapshot.Cells[1]; cell.Controller != "player2" || cell.Mine {
+		t.Errorf("Expected player2 to control (0, 1), got %+v", cell)
+	}
+}
+
+// Test that look and flip return a structured board when JSON is requested
+func TestJSONBoard(t *testing.T) {
+	_, server := newTestServer(t, "1x3\nA\nB\nA\n", time.Second)
+
+	snapshot := getJSON(t, server.URL+"/flip/p1/0,2")
+	if snapshot.Rows != 1 || snapshot.Cols != 3 || snapshot.Version != 1 || len(snapshot.Cells) != 3 {
+		t.Fatalf("Unexpected board %+v", snapshot)
+	}
+	if cell := snapshot.Cells[2]; cell != (CellView{Row: 0, Col: 2, State: "up", Value: "A", Mine: true}) {
+		t.Errorf("Unexpected flipped cell %+v", cell)
+	}
+	if cell := snapshot.Cells[0]; cell != (CellView{Row: 0, Col: 0, State: "down"}) {
+		t.Errorf("Face-down cell should not reveal its value: %+v", cell)
+	}
+	if snapshot.FirstCard == nil || *snapshot.FirstCard != (Position{Row: 0, Col: 2}) {
+		t.Errorf("Expected first card at (0, 2), got %v", snapshot.FirstCard)
+	}
+
+	other := getJSON(t, server.URL+"/look/p2")
+	if other.Cells[2].Mine || other.FirstCard != nil {
+		t.Errorf("p2 should not control anything: %+v", other)
+	}
+
+	// Fără Accept, formatul text rămâne neschimbat
+	if _, body := get(t, server.URL+"/look/p2"); body != "1x3\ndown\ndown\nup A\n" {
+		t.Errorf("Unexpected text board %q", body)
+	}
+}
+
+// Test content negotiation with quality values
+func TestWantsJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", true},
+		{"text/plain", false},
+		{"*/*", false},
+		{"text/plain;q=0.5, application/json", true},
+		{"application/json;q=0.5, text/plain", false},
+		{"application/json;q=0", false},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/look/p1", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		if got := wantsJSON(req); got != test.want {
+			t.Errorf("wantsJSON(%q) = %v, want %v", test.accept, got, test.want)
+		}
+	}
+}
+
+// Test that watch and flip send only the changed cells when the client passes delta
+func TestDeltaResponses(t *testing.T) {
+	_, server := newTestServer(t, "2x2\nA\nB\nA\nB\n", time.Second)
+
+	_, body := get(t, server.URL+"/flip/p1/1,0?delta=0")
+	if body != "2x2\ndelta\n1,0 my A\n" {
+		t.Errorf("Unexpected flip delta %q", body)
+	}
+
+	// Watch-ul cu delta=1 pornește de la versiunea 1, deci nu pierde flip-ul făcut înainte
+	get(t, server.URL+"/flip/p1/0,1")
+	if _, body := get(t, server.URL+"/watch/p2?delta=1"); body != "2x2\ndelta\n0,1 up B\n1,0 up A\n" {
+		t.Errorf("Unexpected watch delta %q", body)
+	}
+
+	if status, _ := get(t, server.URL+"/look/p1?delta=x"); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid delta, got %d", status)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Erorile întoarse de Sessions.Verify
+var (
+	ErrInvalidToken = errors.New("invalid session token")
+	ErrExpiredToken = errors.New("session token expired")
+	ErrRevokedToken = errors.New("session token revoked")
+	ErrStaleToken   = errors.New("session token issued before a server restart")
+	ErrPlayerTaken  = errors.New("player already has a session")
+)
+
+// Sessions emite și verifică token-urile de sesiune ale jucătorilor
+//
+// Un token are forma "{playerID}.{expiră}.{epoch}.{nonce}.{semnătură}", unde expiră
+// este momentul expirării (secunde Unix), epoch identifică pornirea serverului care
+// l-a emis, nonce este aleator (hex), iar semnătura este HMAC-SHA256 cu secretul
+// serverului peste primele patru câmpuri (base64url). Serverul ține minte, doar în
+// memorie, token-urile revocate (până expiră) și ultimul token emis pentru fiecare
+// jucător, ca un playerID care are deja o sesiune să nu poată fi preluat de
+// altcineva. Pentru că această stare se pierde la restart, token-urile emise
+// înainte de restart sunt respinse (epoch diferit), chiar dacă secretul e același:
+// altfel un token revocat ar redeveni valid, iar /join/ ar putea prelua o sesiune
+// în curs. Jucătorii intră din nou cu /join/ după restart.
+//
+// Representation Invariants:
+//   - len(secret) > 0 și ttl > 0
+//   - epoch este diferit la fiecare pornire a serverului
+//   - revoked conține doar nonce-uri de token-uri semnate de acest Sessions
+//   - issued[playerID] este ultimul token emis pentru playerID și nu e în revoked
+//
+// Thread Safety:
+//   - revoked și issued sunt protejate de mu; celelalte câmpuri nu se schimbă
+//     după creare, cu excepția lui now (SetClock) și random (în teste), doar
+//     înainte de folosire
+type Sessions struct {
+	secret  []byte               // Secretul pentru HMAC
+	ttl     time.Duration        // Durata de viață a unui token
+	epoch   string               // Identifică această pornire a serverului (momentul creării, base36)
+	now     func() time.Time     // Ceasul (time.Now, înlocuibil în teste)
+	random  io.Reader            // Sursa nonce-urilor (crypto/rand.Reader, înlocuibilă în teste)
+	mu      sync.Mutex           // Protejează revoked și issued
+	revoked map[string]time.Time // Nonce-urile token-urilor revocate, cu momentul expirării lor
+	issued  map[string]session   // Ultimul token emis pentru fiecare jucător
+}
+
+// session descrie un token emis, fără semnătură
+type session struct {
+	nonce   string    // Valoarea aleatoare care identifică token-ul
+	expires time.Time // Momentul expirării
+}
+
+// NewSessions creează un emitent de token-uri
+//
+// Specification:
+//
+//	Parameters:
+//	  - secret: secretul serverului (nevid)
+//	  - ttl: cât timp este valid un token (> 0)
+//	Returns:
+//	  - *Sessions: emitent nou, fără token-uri revocate; token-urile emise de alt
+//	    Sessions (ex: înainte de un restart) nu sunt acceptate
+func NewSessions(secret []byte, ttl time.Duration) *Sessions {
+	return &Sessions{
+		secret:  append([]byte(nil), secret...),
+		ttl:     ttl,
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		now:     time.Now,
+		random:  rand.Reader,
+		revoked: make(map[string]time.Time),
+		issued:  make(map[string]session),
+	}
+}
+
+// SetClock înlocuiește ceasul folosit pentru expirare (ex: în teste)
+func (s *Sessions) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Issue emite un token nou pentru playerID
+//
+// Specification:
+//
+//	Parameters:
+//	  - playerID: un ID valid (IsValidPlayerID)
+//	  - current: token-ul prezentat de client ("" dacă nu are); un token valid al
+//	    lui playerID este înlocuit de cel nou (reînnoire)
+//	Returns:
+//	  - string: token-ul, acceptat de Verify până la expirare sau revocare
+//	  - time.Time: momentul expirării (trunchiat la secundă)
+//	  - error: ErrPlayerTaken dacă playerID are deja un token valid, emis de acest
+//	    Sessions, iar current nu e acel token; altă eroare dacă nonce-ul nu poate
+//	    fi generat (nimic nu se schimbă)
+//	Postconditions:
+//	  - La reînnoire, current este revocat
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (s *Sessions) Issue(playerID, current string) (string, time.Time, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner, err := s.verify(current, now)
+	renew := err == nil && owner == playerID
+	if old, ok := s.issued[playerID]; ok && now.Before(old.expires) && !renew {
+		return "", time.Time{}, ErrPlayerTaken
+	}
+	nonce := make([]byte, 8)
+	if _, err := io.ReadFull(s.random, nonce); err != nil {
+		return "", time.Time{}, fmt.Errorf("generating session token: %w", err)
+	}
+	if renew {
+		s.revoke(current, now)
+	}
+	issued := session{nonce: hex.EncodeToString(nonce), expires: now.Add(s.ttl).Truncate(time.Second)}
+	payload := fmt.Sprintf("%s.%d.%s.%s", playerID, issued.expires.Unix(), s.epoch, issued.nonce)
+	s.issued[playerID] = issued
+	return payload + "." + s.sign(payload), issued.expires, nil
+}
+
+// Active spune dacă playerID are un token valid emis de acest Sessions
+//
+// Specification:
+//
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (s *Sessions) Active(playerID string) bool {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issued, ok := s.issued[playerID]
+	return ok && now.Before(issued.expires)
+}
+
+// Verify verifică un token și returnează jucătorul pentru care a fost emis
+//
+// Specification:
+//
+//	Returns:
+//	  - string: playerID-ul din token, dacă token-ul e valid
+//	  - error: ErrInvalidToken dacă token-ul e malformat sau semnătura nu corespunde,
+//	    ErrStaleToken dacă a fost emis înainte de un restart, ErrExpiredToken dacă
+//	    a expirat, ErrRevokedToken dacă a fost revocat
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (s *Sessions) Verify(token string) (string, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.verify(token, now)
+}
+
+// verify este Verify la momentul now
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține s.mu
+func (s *Sessions) verify(token string, now time.Time) (string, error) {
+	playerID, expires, nonce, err := s.parse(token)
+	if err != nil {
+		return "", err
+	}
+	if !now.Before(expires) {
+		return "", ErrExpiredToken
+	}
+	if _, revoked := s.revoked[nonce]; revoked {
+		return "", ErrRevokedToken
+	}
+	return playerID, nil
+}
+
+// Revoke invalidează un token înainte de expirare
+//
+// Specification:
+//
+//	Returns:
+//	  - error: eroarea lui Verify dacă token-ul nu e (încă) valid
+//	Postconditions:
+//	  - Verify(token) returnează ErrRevokedToken
+//	  - Jucătorul token-ului poate primi un token nou prin Issue
+//	  - Token-urile revocate care au expirat între timp sunt uitate
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (s *Sessions) Revoke(token string) error {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.verify(token, now); err != nil {
+		return err
+	}
+	s.revoke(token, now)
+	return nil
+}
+
+// revoke adaugă un token valid în revoked și îl scoate din issued
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține s.mu
+//	  - s.verify(token, now) nu a returnat eroare
+func (s *Sessions) revoke(token string, now time.Time) {
+	for old, oldExpires := range s.revoked {
+		if !now.Before(oldExpires) {
+			delete(s.revoked, old)
+		}
+	}
+	playerID, expires, nonce, _ := s.parse(token)
+	s.revoked[nonce] = expires
+	if s.issued[playerID].nonce == nonce {
+		delete(s.issued, playerID)
+	}
+}
+
+// parse separă câmpurile unui token, verifică semnătura și că a fost emis de acest Sessions
+func (s *Sessions) parse(token string) (string, time.Time, string, error) {
+	fields := strings.Split(token, ".")
+	if len(fields) != 5 || !IsValidPlayerID(fields[0]) {
+		return "", time.Time{}, "", ErrInvalidToken
+	}
+	payload := strings.Join(fields[:4], ".")
+	if !hmac.Equal([]byte(fields[4]), []byte(s.sign(payload))) {
+		return "", time.Time{}, "", ErrInvalidToken
+	}
+	seconds, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, "", ErrInvalidToken
+	}
+	if fields[2] != s.epoch {
+		return "", time.Time{}, "", ErrStaleToken
+	}
+	return fields[0], time.Unix(seconds, 0), fields[3], nil
+}
+
+// sign returnează semnătura HMAC-SHA256 a lui payload, în base64url fără padding
+func (s *Sessions) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// requestToken extrage token-ul de sesiune dintr-un request
+//
+// Specification:
+//
+//	Returns:
+//	  - string: token-ul din header-ul "Authorization: Bearer {token}", altfel din
+//	    parametrul ?token= (pentru WebSocket și EventSource); "" dacă lipsește
+func requestToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.URL.Query().Get("token")
+}
+
+// authorize verifică dacă token-ul îi permite lui playerID să modifice tabla
+//
+// Specification:
+//
+//	Parameters:
+//	  - sessions: emitentul token-urilor, sau nil dacă serverul nu cere autentificare
+//	  - token: token-ul prezentat de client ("" dacă lipsește)
+//	  - playerID: jucătorul în numele căruia se face operația
+//	Returns:
+//	  - int: 0 dacă operația e permisă, altfel statusul HTTP: 401 pentru un token
+//	    lipsă, invalid, expirat sau revocat, 403 pentru token-ul altui jucător
+//	  - string: motivul refuzului
+func authorize(sessions *Sessions, token, playerID string) (int, string) {
+	if sessions == nil {
+		return 0, ""
+	}
+	if token == "" {
+		return http.StatusUnauthorized, "Missing session token: call /join/" + playerID + " first"
+	}
+	owner, err := sessions.Verify(token)
+	if err != nil {
+		return http.StatusUnauthorized, err.Error()
+	}
+	if owner != playerID {
+		return http.StatusForbidden, fmt.Sprintf("Session token belongs to %s, not %s", owner, playerID)
+	}
+	return 0, ""
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSessionServer pornește un server de test care cere token-uri de sesiune
+func newSessionServer(t *testing.T, boardText string) (*Sessions, *httptest.Server) {
+	t.Helper()
+	board, err := ParseBoard(strings.NewReader(boardText), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse board: %v", err)
+	}
+	sessions := NewSessions([]byte("test secret"), time.Hour)
+	server := NewServer(board, "", time.Second)
+	server.SetSessions(sessions)
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+	return sessions, httpServer
+}
+
+// join face /join/ și returnează token-ul emis
+func join(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected join to succeed, got %d", resp.StatusCode)
+	}
+	return resp.Header.Get("X-Session-Token")
+}
+
+// Test that tokens verify until they expire or are revoked, and that forged tokens fail
+func TestSessionTokens(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	sessions := NewSessions([]byte("secret"), time.Hour)
+	sessions.SetClock(clock.Now)
+
+	token, _, err := sessions.Issue("player1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player, err := sessions.Verify(token); err != nil || player != "player1" {
+		t.Errorf("Expected a valid token for player1, got %q %v", player, err)
+	}
+	forged := "player2" + strings.TrimPrefix(token, "player1")
+	if _, err := sessions.Verify(forged); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a forged token to be invalid, got %v", err)
+	}
+	if _, err := NewSessions([]byte("other"), time.Hour).Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a token signed with another secret to be invalid, got %v", err)
+	}
+	// După un restart, revoked și issued sunt goale: token-urile vechi nu mai sunt acceptate
+	restarted := NewSessions([]byte("secret"), time.Hour)
+	restarted.SetClock(clock.Now)
+	if _, err := restarted.Verify(token); !errors.Is(err, ErrStaleToken) {
+		t.Errorf("Expected a token from before the restart to be stale, got %v", err)
+	}
+
+	// Un jucător cu sesiune nu poate fi preluat, dar își poate reînnoi token-ul
+	if _, _, err := sessions.Issue("player1", ""); !errors.Is(err, ErrPlayerTaken) {
+		t.Errorf("Expected player1 to be taken, got %v", err)
+	}
+	renewed, _, err := sessions.Issue("player1", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Verify(token); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("Expected the renewed token to be revoked, got %v", err)
+	}
+
+	// Dacă nonce-ul nu poate fi generat, Issue returnează eroarea fără să schimbe nimic
+	sessions.random = strings.NewReader("")
+	if _, _, err := sessions.Issue("player1", renewed); err == nil {
+		t.Error("Expected an error when the random source fails")
+	}
+	if _, err := sessions.Verify(renewed); err != nil {
+		t.Errorf("A failed renewal should keep the current token, got %v", err)
+	}
+	sessions.random = rand.Reader
+
+	clock.Advance(time.Hour)
+	if _, err := sessions.Verify(renewed); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("Expected the token to expire, got %v", err)
+	}
+	if _, _, err := sessions.Issue("player1", ""); err != nil {
+		t.Errorf("Expected player1 to be free after expiry, got %v", err)
+	}
+}
+
+// Test that flip, replace and map need the player's token while look stays open
+func TestSessionServer(t *testing.T) {
+	_, server := newSessionServer(t, "1x2\nA\nA\n")
+
+	if status, _ := get(t, server.URL+"/flip/player1/0,0"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", status)
+	}
+	token := join(t, server.URL+"/join/player1")
+	if token == "" {
+		t.Fatal("Expected /join/ to issue a token")
+	}
+	if status, _ := get(t, server.URL+"/join/player1"); status != http.StatusConflict {
+		t.Errorf("Expected 409 when joining as a taken player, got %d", status)
+	}
+	if status, _ := get(t, server.URL+"/flip/player2/0,0?token="+token); status != http.StatusForbidden {
+		t.Errorf("Expected 403 with another player's token, got %d", status)
+	}
+
+	status, body := get(t, server.URL+"/flip/player1/0,0?token="+token)
+	if status != http.StatusOK || body != "1x2\nmy A\ndown\n" {
+		t.Errorf("Unexpected flip response %d %q", status, body)
+	}
+	if status, body := get(t, server.URL+"/map/player1?A=B&token="+token); status != http.StatusOK || body != "1x2\nmy B\ndown\n" {
+		t.Errorf("Unexpected map response %d %q", status, body)
+	}
+	if status, body := get(t, server.URL+"/look/watcher"); status != http.StatusOK || body != "1x2\nup B\ndown\n" {
+		t.Errorf("Expected look to stay open, got %d %q", status, body)
+	}
+
+	// După logout, token-ul nu mai e acceptat
+	if status, _ := get(t, server.URL+"/logout/player1?token="+token); status != http.StatusNoContent {
+		t.Errorf("Expected 204 from logout, got %d", status)
+	}
+	if status, _ := get(t, server.URL+"/replace/player1/B/C?token="+token); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with a revoked token, got %d", status)
+	}
+}
+
+// Test that a /join/ refused with 409 leaves the board unchanged
+func TestSessionJoinConflictLeavesBoard(t *testing.T) {
+	board := NewBoard([][]Card{{NewCard("A"), NewCard("A")}})
+	board.SetTurnBased(true)
+	sessions := NewSessions([]byte("test secret"), time.Hour)
+	server := NewServer(board, "", time.Second)
+	server.SetSessions(sessions)
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	// player1 are o sesiune emisă în altă parte (ex: pe alt transport), dar nu a intrat încă
+	if _, _, err := sessions.Issue("player1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := get(t, httpServer.URL+"/join/player1"); status != http.StatusConflict {
+		t.Fatalf("Expected 409 when joining as a taken player, got %d", status)
+	}
+	if active := board.ActivePlayer(); active != "" {
+		t.Errorf("A refused join should not enter the turn order, got %s at turn", active)
+	}
+}
+
+// Test that WebSocket and TCP commands check the token too
+func TestSessionOtherTransports(t *testing.T) {
+	sessions, server := newSessionServer(t, "1x2\nA\nA\n")
+	token := join(t, server.URL+"/join/player1")
+
+	spectator := dialWebSocket(t, server.URL, "/ws/player1")
+	spectator.receive(t)
+	spectator.send(t, "flip 0,0")
+	if _, text := spectator.receive(t); !strings.HasPrefix(text, "error Missing session token") {
+		t.Errorf("Expected the flip to be refused without a token, got %q", text)
+	}
+
+	board := NewBoard([][]Card{{NewCard("A"), NewCard("A")}})
+	tcp := NewTCPServer(board)
+	tcp.SetSessions(sessions)
+	conn, reader := dialTCPServer(t, tcp)
+	if reply := command(t, conn, reader, "FLIP player1 0 0"); !strings.HasPrefix(reply, "ERROR Missing session token") {
+		t.Errorf("Expected TCP flip to be refused without a token, got %q", reply)
+	}
+	if reply := command(t, conn, reader, "FLIP player1 0 0 "+token); reply != "version 1\n1x2\nmy A\ndown\n" {
+		t.Errorf("Unexpected TCP flip reply %q", reply)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CellView este o celulă a tablei, așa cum o vede un jucător sau un spectator
+// Representation Invariants:
+//   - State este "none", "down" sau "up"
+//   - Value != "" dacă și numai dacă State == "up"
+//   - Mine implică State == "up"
+//   - Controller != "" implică State == "up" și !Mine
+type CellView struct {
+	Row        int    `json:"row"`                  // Rândul (0-indexed)
+	Col        int    `json:"col"`                  // Coloana (0-indexed)
+	State      string `json:"state"`                // "none", "down" sau "up"
+	Value      string `json:"value,omitempty"`      // Valoarea cărții, doar dacă e cu fața în sus
+	Mine       bool   `json:"mine"`                 // true dacă jucătorul controlează cartea
+	Controller string `json:"controller,omitempty"` // Cine controlează cartea, doar dacă Viewer.ShowControllers
+}
+
+// PendingTurn sunt cărțile întoarse de un jucător în tura lui curentă, încă neterminată
+type PendingTurn struct {
+	Player string     `json:"player"` // Jucătorul
+	Cards  []Position `json:"cards"`  // Cărțile ținute, în ordinea întoarcerii (prima carte întâi)
+}
+
+// BoardSnapshot este starea tablei văzută de un jucător sau spectator la o anumită versiune
+// Representation Invariants:
+//   - Dacă !Delta: len(Cells) == Rows * Cols, în ordinea rândurilor (Cells[i*Cols+j] e celula (i, j))
+//   - Dacă Delta: Cells conține doar celulele schimbate, sortate după rând și coloană
+//   - Winners != nil implică GameOver
+//   - Pending este sortat după Player și fiecare intrare are cel puțin o carte
+type BoardSnapshot struct {
+	Rows      int           `json:"rows"`              // Numărul de rânduri
+	Cols      int           `json:"cols"`              // Numărul de coloane
+	Version   int           `json:"version"`           // Versiunea tablei
+	Delta     bool          `json:"delta,omitempty"`   // true dacă Cells conține doar celulele schimbate
+	Cells     []CellView    `json:"cells"`             // Celulele, rând cu rând
+	FirstCard *Position     `json:"firstCard"`         // Prima carte întoarsă de jucător, nil dacă nu are (sau e spectator)
+	Turn      string        `json:"turn,omitempty"`    // Jucătorul care este la rând (doar în modul pe ture)
+	Pending   []PendingTurn `json:"pending,omitempty"` // Turele începute ale jucătorilor, doar dacă Viewer.ShowPending
+	GameOver  bool          `json:"gameOver"`          // true dacă toate cărțile au fost eliminate
+	Winners   []string      `json:"winners,omitempty"` // Câștigătorii, dacă jocul s-a terminat
+	Scores    []PlayerScore `json:"scores,omitempty"`  // Clasamentul, doar dacă a fost cerut
+}
+
+// Snapshot returnează starea tablei văzută de un jucător
+//
+// Specification:
+//
+//	Parameters:
+//	  - playerID: jucătorul pentru care se construiește vederea (poate fi oricare string)
+//	Returns:
+//	  - BoardSnapshot: celulele, versiunea, prima carte a jucătorului, jucătorul
+//	    la rând și starea jocului, toate citite sub același lock; Scores este nil
+//	Postconditions:
+//	  - Valorile cărților cu fața în jos nu apar în snapshot
+//	  - Nu creează stare pentru playerID dacă acesta nu a jucat încă
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu.RLock, apoi playerStatesMu)
+func (b *Board) Snapshot(playerID string) BoardSnapshot {
+	return b.SnapshotAs(PlayerView(playerID), -1)
+}
+
+// SnapshotSince returnează doar celulele schimbate după versiunea since
+//
+// Specification:
+//
+//	Parameters:
+//	  - playerID: ca la Snapshot
+//	  - since: ultima versiune pe care o are clientul
+//	Returns:
+//	  - BoardSnapshot: cu Delta == true și celulele schimbate (valoare, față sau
+//	    control) după since, dacă change log-ul acoperă since; altfel același
+//	    rezultat ca Snapshot(playerID)
+//	Postconditions:
+//	  - Aplicând Cells peste tabla de la versiunea since se obține tabla curentă
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu.RLock, apoi playerStatesMu)
+func (b *Board) SnapshotSince(playerID string, since int) BoardSnapshot {
+	return b.SnapshotAs(PlayerView(playerID), since)
+}
+
+// SnapshotAs returnează starea tablei văzută de viewer
+//
+// Specification:
+//
+//	Parameters:
+//	  - viewer: jucătorul sau spectatorul care privește tabla
+//	  - since: ca la SnapshotSince, sau -1 pentru toată tabla
+//	Returns:
+//	  - BoardSnapshot: ca la Snapshot și SnapshotSince, cu diferențele:
+//	      - Pentru RoleSpectator nicio celulă nu are Mine, iar FirstCard este nil
+//	      - Dacă viewer.ShowControllers, cărțile controlate de alții au Controller
+//	      - Dacă viewer.ShowPending, Pending conține cărțile ținute de fiecare
+//	        jucător care și-a început tura (vezi PlayerState.InProgress)
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu.RLock, apoi playerStatesMu)
+func (b *Board) SnapshotAs(viewer Viewer, since int) BoardSnapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if since < 0 {
+		return b.snapshot(viewer, nil)
+	}
+	cells, ok := b.changedSince(since)
+	if !ok {
+		return b.snapshot(viewer, nil)
+	}
+	return b.snapshot(viewer, cells)
+}
+
+// snapshot construiește vederea lui viewer asupra celulelor cells, sau asupra
+// întregii table dacă cells == nil
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu
+func (b *Board) snapshot(viewer Viewer, cells []Position) BoardSnapshot {
+	snapshot := BoardSnapshot{
+		Rows:     b.Rows,
+		Cols:     b.Cols,
+		Version:  b.version,
+		Delta:    cells != nil,
+		Turn:     b.activePlayer(),
+		GameOver: b.gameOver,
+		Winners:  append([]string(nil), b.winners...),
+	}
+	if cells == nil {
+		snapshot.Cells = make([]CellView, 0, b.Rows*b.Cols)
+		for i := 0; i < b.Rows; i++ {
+			for j := 0; j < b.Cols; j++ {
+				snapshot.Cells = append(snapshot.Cells, b.cellView(i, j, viewer))
+			}
+		}
+	} else {
+		snapshot.Cells = make([]CellView, 0, len(cells))
+		for _, pos := range cells {
+			snapshot.Cells = append(snapshot.Cells, b.cellView(pos.Row, pos.Col, viewer))
+		}
+	}
+
+	b.playerStatesMu.Lock()
+	if state, ok := b.playerStates[viewer.ID]; ok && viewer.Role == RolePlayer && state.InProgress() {
+		first := state.Held[0]
+		snapshot.FirstCard = &first
+	}
+	if viewer.ShowPending {
+		for playerID, state := range b.playerStates {
+			if state.InProgress() {
+				held := append([]Position(nil), state.Held...)
+				snapshot.Pending = append(snapshot.Pending, PendingTurn{Player: playerID, Cards: held})
+			}
+		}
+		sort.Slice(snapshot.Pending, func(i, j int) bool {
+			return snapshot.Pending[i].Player < snapshot.Pending[j].Player
+		})
+	}
+	b.playerStatesMu.Unlock()
+
+	return snapshot
+}
+
+// cellView construiește vederea celulei (row, col) pentru viewer
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu
+//	  - 0 <= row < b.Rows, 0 <= col < b.Cols
+func (b *Board) cellView(row, col int, viewer Viewer) CellView {
+	card := b.Cards[row][col]
+	cell := CellView{Row: row, Col: col}
+	switch {
+	case card.Value == "":
+		cell.State = "none"
+	case !card.FaceUp:
+		cell.State = "down"
+	default:
+		cell.State = "up"
+		cell.Value = card.Value
+		cell.Mine = viewer.Role == RolePlayer && card.Controller == viewer.ID
+		if viewer.ShowControllers && !cell.Mine {
+			cell.Controller = card.Controller
+		}
+	}
+	return cell
+}
+
+// Line formatează celula ca linie din FormatBoard: "none", "down", "my X", "up X"
+// sau, dacă se cunoaște cine o controlează, "up X by {controller}"
+func (c CellView) Line() string {
+	switch {
+	case c.State != "up":
+		return c.State
+	case c.Mine:
+		return "my " + c.Value
+	case c.Controller != "":
+		return "up " + c.Value + " by " + c.Controller
+	default:
+		return "up " + c.Value
+	}
+}
+
+// Text formatează snapshot-ul în formatul text al lui FormatBoard
+//
+// Specification:
+//
+//	Returns:
+//	  - string: "RxC", câte o linie pentru fiecare celulă (CellView.Line), linia
+//	    "turn {player}" dacă Turn != "", câte o linie "pending {player} {row},{col} ..."
+//	    pentru fiecare tură din Pending, linia FormatGameOver dacă jocul s-a terminat
+//	    și, dacă Scores != nil, clasamentul cu prefixul "score "
+//	  - Dacă Delta: după "RxC" urmează linia "delta", iar fiecare celulă apare ca
+//	    "{row},{col} {CellView.Line}", ex: "0,1 up A"
+func (s BoardSnapshot) Text() string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%dx%d\n", s.Rows, s.Cols))
+	if s.Delta {
+		result.WriteString("delta\n")
+	}
+	for _, cell := range s.Cells {
+		if s.Delta {
+			result.WriteString(fmt.Sprintf("%d,%d ", cell.Row, cell.Col))
+		}
+		result.WriteString(cell.Line())
+		result.WriteString("\n")
+	}
+	if s.Turn != "" {
+		result.WriteString("turn " + s.Turn + "\n")
+	}
+	for _, pending := range s.Pending {
+		result.WriteString("pending " + pending.Player)
+		for _, pos := range pending.Cards {
+			result.WriteString(fmt.Sprintf(" %d,%d", pos.Row, pos.Col))
+		}
+		result.WriteString("\n")
+	}
+	if s.GameOver {
+		result.WriteString(FormatGameOver(s.Winners))
+	}
+	if s.Scores != nil {
+		result.WriteString(FormatScores(s.Scores, "score "))
+	}
+	return result.String()
+}
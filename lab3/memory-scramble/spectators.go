@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ViewerRole spune cum vede tabla cel care o privește
+type ViewerRole int
+
+const (
+	RolePlayer    ViewerRole = iota // Jucător: cărțile pe care le controlează apar ca "my X"
+	RoleSpectator                   // Spectator: nicio carte nu apare ca "my X"
+)
+
+// Viewer descrie cine privește tabla și ce vede
+// Representation Invariants:
+//   - ID este un playerID valid (IsValidPlayerID)
+type Viewer struct {
+	Role            ViewerRole // Rolul: jucător sau spectator
+	ID              string     // Jucătorul sau spectatorul
+	ShowControllers bool       // true dacă cărțile controlate de alții apar cu controlorul ("up X by player2")
+	ShowPending     bool       // true dacă snapshot-ul listează cărțile ținute de fiecare jucător în tura curentă
+}
+
+// PlayerView returnează vederea obișnuită a jucătorului playerID
+func PlayerView(playerID string) Viewer {
+	return Viewer{Role: RolePlayer, ID: playerID}
+}
+
+// SpectatorView returnează vederea spectatorului spectatorID, cu sau fără controlori
+func SpectatorView(spectatorID string, showControllers bool) Viewer {
+	return Viewer{Role: RoleSpectator, ID: spectatorID, ShowControllers: showControllers}
+}
+
+// SpectatorError descrie o operație refuzată pentru că ID-ul aparține unui spectator
+type SpectatorError struct {
+	Spectator string // Spectatorul care a încercat operația
+}
+
+// Error implementează interfața error
+func (e *SpectatorError) Error() string {
+	return fmt.Sprintf("%s is a spectator and cannot play", e.Spectator)
+}
+
+// Spectate înregistrează spectatorID ca spectator al tablei, cât timp o privește
+//
+// Specification:
+//
+//	Parameters:
+//	  - spectatorID: identificatorul spectatorului
+//	Returns:
+//	  - func(): încheie înregistrarea; se apelează când spectatorul pleacă
+//	    (ex: la închiderea conexiunii); apelurile repetate nu au efect
+//	  - error: non-nil dacă spectatorID a jucat deja sau a intrat în joc (are o stare)
+//	Postconditions:
+//	  - Până la ultimul release, FlipCard, Replace, Map și Join refuză spectatorID
+//	    cu *SpectatorError; după aceea spectatorID poate juca
+//	  - Spectatorii țin de conexiuni, deci nu sunt salvați în snapshot-uri și journal
+//	Thread Safety:
+//	  - Funcția și release sunt thread-safe (folosesc mu, apoi playerStatesMu)
+func (b *Board) Spectate(spectatorID string) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.playerStatesMu.Lock()
+	_, playing := b.playerStates[spectatorID]
+	b.playerStatesMu.Unlock()
+	if playing {
+		return nil, fmt.Errorf("%s is already playing", spectatorID)
+	}
+
+	if b.spectators == nil {
+		b.spectators = make(map[string]int)
+	}
+	b.spectators[spectatorID]++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if b.spectators[spectatorID]--; b.spectators[spectatorID] <= 0 {
+				delete(b.spectators, spectatorID)
+			}
+		})
+	}
+	return release, nil
+}
+
+// checkPlayer refuză operațiile unui spectator
+//
+// Specification:
+//
+//	Returns:
+//	  - error: *SpectatorError dacă playerID este spectator, altfel nil
+//	Preconditions:
+//	  - Apelantul deține b.mu
+func (b *Board) checkPlayer(playerID string) error {
+	if b.spectators[playerID] > 0 {
+		return &SpectatorError{Spectator: playerID}
+	}
+	return nil
+}
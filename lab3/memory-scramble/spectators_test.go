@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Test that spectators never see "my X", may see controllers and cannot play
+func TestSpectatorView(t *testing.T) {
+	board := NewBoard([][]Card{{NewCard("A"), NewCard("A"), NewCard("B")}})
+	ctx := context.Background()
+	FlipCard(ctx, board, 0, 0, "player1")
+
+	release, err := board.Spectate("viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := board.Spectate("player1"); err == nil {
+		t.Error("Expected a player to be refused as spectator")
+	}
+	if got := board.FormatBoardAs(SpectatorView("viewer", false)); got != "1x3\nup A\ndown\ndown\n" {
+		t.Errorf("Unexpected spectator view %q", got)
+	}
+	if got := board.FormatBoardAs(SpectatorView("viewer", true)); got != "1x3\nup A by player1\ndown\ndown\n" {
+		t.Errorf("Unexpected spectator view with controllers %q", got)
+	}
+	if got := board.FormatBoardAs(PlayerView("player1")); got != board.FormatBoard("player1") {
+		t.Errorf("PlayerView should match FormatBoard, got %q", got)
+	}
+
+	var spectatorErr *SpectatorError
+	if err := FlipCard(ctx, board, 0, 2, "viewer"); !errors.As(err, &spectatorErr) {
+		t.Errorf("Expected the spectator's flip to be refused, got %v", err)
+	}
+	if _, err := Replace(board, "viewer", "A", "C"); !errors.As(err, &spectatorErr) {
+		t.Errorf("Expected the spectator's replace to be refused, got %v", err)
+	}
+	if got := board.FormatBoard("viewer"); got != "1x3\nup A\ndown\ndown\n" {
+		t.Errorf("Refused operations should not change the board, got %q", got)
+	}
+
+	// După ce spectatorul pleacă, ID-ul lui poate juca
+	release()
+	release()
+	if err := FlipCard(ctx, board, 0, 2, "viewer"); err != nil {
+		t.Errorf("Expected the former spectator to play, got %v", err)
+	}
+}
+
+// Test the spectator endpoints and that flip and replace answer 403 for spectators
+func TestSpectatorServer(t *testing.T) {
+	_, server := newTestServer(t, "1x4\nA\nA\nB\nB\n", time.Second)
+	get(t, server.URL+"/flip/player1/0,0")
+
+	status, body := get(t, server.URL+"/spectate/look/viewer?controllers")
+	if status != http.StatusOK || body != "1x4\nup A by player1\ndown\ndown\ndown\n" {
+		t.Errorf("Unexpected spectator look %d %q", status, body)
+	}
+	if status, _ := get(t, server.URL+"/spectate/look/player1"); status != http.StatusConflict {
+		t.Errorf("Expected 409 when a player tries to spectate, got %d", status)
+	}
+
+	// Cât timp stream-ul spectatorului e deschis, ID-ul lui nu poate juca
+	stream, err := http.Get(server.URL + "/spectate/events/viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := get(t, server.URL+"/flip/viewer/0,1"); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a spectator's flip, got %d", status)
+	}
+	if status, _ := get(t, server.URL+"/replace/viewer/A/B"); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a spectator's replace, got %d", status)
+	}
+	stream.Body.Close()
+
+	done := make(chan string, 1)
+	go func() {
+		_, body := get(t, server.URL+"/spectate/watch/viewer?since=1")
+		done <- body
+	}()
+	get(t, server.URL+"/flip/player1/0,1")
+	select {
+	case body := <-done:
+		if body != "1x4\nup A\nup A\ndown\ndown\n" {
+			t.Errorf("Unexpected spectator watch response %q", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Spectator watch was not woken by the flip")
+	}
+}
+
+// Test that spectating an ID does not lock out the player who owns it
+func TestSpectatorCannotSquatPlayer(t *testing.T) {
+	_, server := newTestServer(t, "1x4\nA\nA\nB\nB\n", time.Second)
+	if status, _ := get(t, server.URL+"/spectate/look/player1"); status != http.StatusOK {
+		t.Fatalf("Expected player1 to be spectated, got %d", status)
+	}
+	if status, body := get(t, server.URL+"/flip/player1/0,0"); status != http.StatusOK || body != "1x4\nmy A\ndown\ndown\ndown\n" {
+		t.Errorf("Expected player1 to play after being spectated, got %d %q", status, body)
+	}
+
+	// Cu sesiuni, ID-ul unui jucător cu token nu poate fi spectat deloc
+	_, sessionServer := newSessionServer(t, "1x2\nA\nA\n")
+	token := join(t, sessionServer.URL+"/join/player1")
+	if status, _ := get(t, sessionServer.URL+"/spectate/events/player1"); status != http.StatusConflict {
+		t.Errorf("Expected 409 when spectating a player with a session, got %d", status)
+	}
+	if status, _ := get(t, sessionServer.URL+"/flip/player1/0,0?token="+token); status != http.StatusOK {
+		t.Errorf("Expected the session owner to play, got %d", status)
+	}
+}
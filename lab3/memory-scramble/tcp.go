@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxTCPLine este lungimea maximă a unei comenzi primite pe TCP
+const maxTCPLine = 4096
+
+// TCPServer servește o tablă printr-un protocol text pe linii, fără HTTP
+// Comenzi (câte una pe linie, verbul poate fi scris cu orice majuscule):
+//   - LOOK {playerID}
+//   - FLIP {playerID} {row} {col} [{token}]
+//   - REPLACE {playerID} {from} {to} [{token}]
+//   - WATCH {playerID} [{version}]
+//
+// Fiecare răspuns este o linie "version {N}" urmată de tabla în formatul lui
+// FormatBoard la versiunea N, sau o linie "ERROR {mesaj}", urmat de o linie goală
+// care marchează sfârșitul răspunsului. Un client care trimite "WATCH p N" cu
+// ultima versiune primită nu pierde nicio schimbare între două WATCH-uri.
+// Dacă serverul cere sesiuni, FLIP și REPLACE primesc la final token-ul
+// jucătorului, obținut prin HTTP la /join/{playerID}.
+//
+// Representation Invariants:
+//   - board != nil
+//
+// Thread Safety:
+//   - TCPServer nu are stare mutabilă proprie după SetSessions; folosește tabla
+//     returnată de board, care este thread-safe, deci jucătorii pe TCP și pe HTTP
+//     pot juca pe aceeași tablă
+type TCPServer struct {
+	board    func() (*Board, error) // Tabla servită acum (aceeași cu a unui Server HTTP), căutată la fiecare comandă
+	sessions *Sessions              // Emitentul token-urilor de sesiune, sau nil dacă nu se cere autentificare
+}
+
+// NewTCPServer creează un server TCP pentru tabla dată
+//
+// Specification:
+//
+//	Parameters:
+//	  - board: tabla servită (nu trebuie nil); poate fi servită și prin HTTP
+//	Returns:
+//	  - *TCPServer: server nou care respectă representation invariants
+func NewTCPServer(board *Board) *TCPServer {
+	return &TCPServer{board: func() (*Board, error) { return board, nil }}
+}
+
+// NewLobbyTCPServer creează un server TCP pentru jocul id din lobby
+//
+// Specification:
+//
+//	Parameters:
+//	  - lobby: lobby-ul care servește și HTTP
+//	  - id: jocul servit (ex: DefaultGameID)
+//	Returns:
+//	  - *TCPServer: server nou care respectă representation invariants
+//	Postconditions:
+//	  - Jocul este căutat în lobby la fiecare comandă: după ce jocul este șters,
+//	    comenzile primesc "ERROR Unknown game", iar după ce este creat din nou
+//	    joacă pe tabla nouă
+func NewLobbyTCPServer(lobby *Lobby, id string) *TCPServer {
+	return &TCPServer{board: func() (*Board, error) {
+		game, ok := lobby.Game(id)
+		if !ok {
+			return nil, errors.New("Unknown game " + strconv.Quote(id))
+		}
+		return game.Board, nil
+	}}
+}
+
+// SetSessions cere token-uri de sesiune pentru FLIP și REPLACE
+//
+// Specification:
+//
+//	Parameters:
+//	  - sessions: emitentul token-urilor (același cu al serverului HTTP), sau nil
+//	Preconditions:
+//	  - Serverul nu servește încă conexiuni
+func (s *TCPServer) SetSessions(sessions *Sessions) {
+	s.sessions = sessions
+}
+
+// Serve acceptă conexiuni pe listener și le servește, fiecare în goroutine proprie
+//
+// Specification:
+//
+//	Returns:
+//	  - error: eroarea lui listener.Accept (ex: după listener.Close)
+//	Effects:
+//	  - Blochează până când listener-ul este închis
+func (s *TCPServer) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn execută comenzile unei conexiuni, în ordine
+//
+// Specification:
+//
+//	Postconditions:
+//	  - Fiecare comandă primește exact un răspuns, în ordinea comenzilor; cel
+//	    mult maxQueuedCommands așteaptă în coadă, altfel conexiunea este închisă
+//	  - Conexiunea este citită tot timpul, deci la o eroare de citire (ex:
+//	    conexiune resetată) sau la depășirea cozii un FLIP sau WATCH care
+//	    așteaptă este anulat, iar comenzile din coadă nu mai sunt executate
+//	  - La EOF (clientul a închis doar scrierea) comenzile din coadă se termină
+//	    normal și primesc răspuns
+//	  - Conexiunea este închisă la final
+func (s *TCPServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Citește comenzile separat, ca o deconectare să anuleze comanda care așteaptă;
+	// cititorul nu se blochează niciodată, comenzile noi așteaptă în coada lines
+	lines := make(chan string, maxQueuedCommands)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(lines)
+		scanner := bufio.NewScanner(conn)
+		scanner.Buffer(make([]byte, 0, maxTCPLine), maxTCPLine)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			default:
+				log.Printf("TCP connection from %s: more than %d queued commands, closing", conn.RemoteAddr(), maxQueuedCommands)
+				cancel()
+				return
+			}
+		}
+		if scanner.Err() != nil {
+			// Clientul a plecat: nu mai are cine să primească răspunsurile
+			cancel()
+		}
+	}()
+
+	writer := bufio.NewWriter(conn)
+	for line := range lines {
+		if ctx.Err() != nil {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		reply, ok := s.execute(ctx, line)
+		if !ok {
+			break
+		}
+		writer.WriteString(reply)
+		writer.WriteString("\n")
+		if writer.Flush() != nil {
+			break
+		}
+	}
+	cancel()
+	// Deblochează cititorul, dacă încă așteaptă date
+	conn.Close()
+	wg.Wait()
+}
+
+// execute rulează o comandă și returnează răspunsul
+//
+// Specification:
+//
+//	Returns:
+//	  - string: "version {N}\n" și tabla (FormatBoardWithVersion) după comandă,
+//	    sau "ERROR {mesaj}\n" (inclusiv dacă jocul servit nu mai există)
+//	  - bool: false dacă ctx a fost anulat în timpul așteptării (nu există răspuns)
+func (s *TCPServer) execute(ctx context.Context, line string) (string, bool) {
+	fields := strings.Fields(line)
+	verb := strings.ToUpper(fields[0])
+	args := fields[1:]
+
+	if len(args) == 0 || !IsValidPlayerID(args[0]) {
+		return "ERROR Invalid player ID\n", true
+	}
+	playerID := args[0]
+
+	// Token-ul de sesiune, dacă există, este ultimul argument al lui FLIP și REPLACE
+	token := ""
+	if (verb == "FLIP" || verb == "REPLACE") && len(args) == 4 {
+		token, args = args[3], args[:3]
+	}
+	if (verb == "FLIP" || verb == "REPLACE") && len(args) == 3 {
+		if status, message := authorize(s.sessions, token, playerID); status != 0 {
+			return "ERROR " + message + "\n", true
+		}
+	}
+
+	board, err := s.board()
+	if err != nil {
+		return "ERROR " + err.Error() + "\n", true
+	}
+
+	switch {
+	case verb == "LOOK" && len(args) == 1:
+	case verb == "FLIP" && len(args) == 3:
+		row, rowErr := strconv.Atoi(args[1])
+		col, colErr := strconv.Atoi(args[2])
+		if rowErr != nil || colErr != nil || !board.InBounds(row, col) {
+			return fmt.Sprintf("ERROR Invalid position %s %s\n", args[1], args[2]), true
+		}
+		if err := FlipCard(ctx, board, row, col, playerID); err != nil {
+			var ruleErr *RuleError
+			var turnErr *TurnError
+			var spectatorErr *SpectatorError
+			if errors.As(err, &ruleErr) || errors.As(err, &turnErr) || errors.As(err, &spectatorErr) || errors.Is(err, ErrGameDeleted) {
+				return "ERROR " + err.Error() + "\n", true
+			}
+			return "", false
+		}
+	case verb == "REPLACE" && len(args) == 3:
+		if !IsValidCardValue(args[1]) || !IsValidCardValue(args[2]) {
+			return "ERROR Invalid card value\n", true
+		}
+		if _, err := Replace(board, playerID, args[1], args[2]); err != nil {
+			return "ERROR " + err.Error() + "\n", true
+		}
+	case verb == "WATCH" && (len(args) == 1 || len(args) == 2):
+		since := board.Version()
+		if len(args) == 2 {
+			var err error
+			if since, err = strconv.Atoi(args[1]); err != nil || since < 0 {
+				return "ERROR Invalid version " + args[1] + "\n", true
+			}
+		}
+		if _, err := board.WaitForChange(ctx, since); err != nil {
+			if errors.Is(err, ErrGameDeleted) {
+				return "ERROR " + err.Error() + "\n", true
+			}
+			return "", false
+		}
+	default:
+		return "ERROR Unknown command " + strconv.Quote(line) + "\n", true
+	}
+
+	text, version := board.FormatBoardWithVersion(playerID)
+	return fmt.Sprintf("version %d\n%s", version, text), true
+}
+
+// ListenTCP pornește serverul TCP pe addr, în fundal
+//
+// Specification:
+//
+//	Returns:
+//	  - net.Listener: listener-ul deschis (închiderea lui oprește serverul)
+//	  - error: non-nil dacă addr nu poate fi ascultat
+func (s *TCPServer) ListenTCP(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := s.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) {
+			log.Printf("TCP server on %s stopped: %v", addr, err)
+		}
+	}()
+	return listener, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialTCP pornește un TCPServer pentru board și se conectează la el
+func dialTCP(t *testing.T, board *Board) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	return dialTCPServer(t, NewTCPServer(board))
+}
+
+// dialTCPServer pornește server și se conectează la el
+func dialTCPServer(t *testing.T, server *TCPServer) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	listener, err := server.ListenTCP("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return conn, bufio.NewReader(conn)
+}
+
+// command trimite o comandă și citește răspunsul, până la linia goală
+func command(t *testing.T, conn net.Conn, reader *bufio.Reader, line string) string {
+	t.Helper()
+	if _, err := conn.Write([]byte(line + "\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var reply strings.Builder
+	for {
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Reading reply to %q: %v", line, err)
+		}
+		if text == "\n" {
+			return reply.String()
+		}
+		reply.WriteString(text)
+	}
+}
+
+// Test the TCP commands and that TCP and HTTP players share one board
+func TestTCPProtocol(t *testing.T) {
+	board, server := newTestServer(t, "1x2\nA\nB\n", time.Second)
+	conn, reader := dialTCP(t, board)
+
+	if reply := command(t, conn, reader, "LOOK p1"); reply != "version 0\n1x2\ndown\ndown\n" {
+		t.Errorf("Unexpected LOOK reply %q", reply)
+	}
+	if reply := command(t, conn, reader, "flip p1 0 0"); reply != "version 1\n1x2\nmy A\ndown\n" {
+		t.Errorf("Unexpected FLIP reply %q", reply)
+	}
+
+	// Jucătorul HTTP vede cartea întoarsă pe TCP
+	if _, body := get(t, server.URL+"/look/p2"); body != "1x2\nup A\ndown\n" {
+		t.Errorf("HTTP player should see the TCP flip, got %q", body)
+	}
+
+	if reply := command(t, conn, reader, "REPLACE p1 A C"); reply != "version 2\n1x2\nmy C\ndown\n" {
+		t.Errorf("Unexpected REPLACE reply %q", reply)
+	}
+	if reply := command(t, conn, reader, "WATCH p1 0"); reply != "version 2\n1x2\nmy C\ndown\n" {
+		t.Errorf("WATCH from an old version should return at once, got %q", reply)
+	}
+	if reply := command(t, conn, reader, "FLIP p1 5 5"); reply != "ERROR Invalid position 5 5\n" {
+		t.Errorf("Unexpected error reply %q", reply)
+	}
+	if reply := command(t, conn, reader, "JUMP p1"); !strings.HasPrefix(reply, "ERROR Unknown command") {
+		t.Errorf("Unexpected error reply %q", reply)
+	}
+	if reply := command(t, conn, reader, "LOOK bad-id"); reply != "ERROR Invalid player ID\n" {
+		t.Errorf("Unexpected error reply %q", reply)
+	}
+}
+
+// Test that WATCH wakes up on a flip made over HTTP
+func TestTCPWatchSeesHTTPFlip(t *testing.T) {
+	board, server := newTestServer(t, "1x2\nA\nB\n", time.Second)
+	conn, reader := dialTCP(t, board)
+
+	replies := make(chan string)
+	go func() { replies <- command(t, conn, reader, "WATCH p1 0") }()
+
+	get(t, server.URL+"/flip/p2/0,1")
+	if reply := <-replies; reply != "version 1\n1x2\ndown\nup B\n" {
+		t.Errorf("Unexpected WATCH reply %q", reply)
+	}
+}
+
+// Test that a client resuming WATCH from the last version it saw misses no change
+func TestTCPWatchResume(t *testing.T) {
+	board, server := newTestServer(t, "1x2\nA\nB\n", time.Second)
+	conn, reader := dialTCP(t, board)
+
+	reply := command(t, conn, reader, "LOOK p1")
+	var version int
+	if _, err := fmt.Sscanf(reply, "version %d\n", &version); err != nil {
+		t.Fatalf("Expected a version line in %q", reply)
+	}
+
+	// Schimbarea vine între două comenzi: WATCH de la versiunea văzută o întoarce imediat
+	get(t, server.URL+"/flip/p2/0,0")
+	if reply := command(t, conn, reader, fmt.Sprintf("WATCH p1 %d", version)); reply != "version 1\n1x2\nup A\ndown\n" {
+		t.Errorf("Unexpected resumed WATCH reply %q", reply)
+	}
+}
+
+// Test that a client which closes only its side still gets the replies to queued commands
+func TestTCPHalfCloseGetsReplies(t *testing.T) {
+	board, server := newTestServer(t, "1x2\nA\nB\n", time.Second)
+	conn, reader := dialTCP(t, board)
+
+	conn.Write([]byte("WATCH p1 0\nLOOK p1\n"))
+	if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+	get(t, server.URL+"/flip/p2/0,1")
+
+	reply, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "version 1\n1x2\ndown\nup B\n\nversion 1\n1x2\ndown\nup B\n\n"; string(reply) != want {
+		t.Errorf("Expected replies to WATCH and LOOK after the half-close, got %q", reply)
+	}
+}
+
+// Test that a TCP server for a lobby game follows the game when it is deleted and created again
+func TestTCPFollowsLobbyGame(t *testing.T) {
+	lobby := NewLobby(".", "", time.Second)
+	if _, err := lobby.CreateGame(DefaultGameID, NewBoard([][]Card{{NewCard("A"), NewCard("A")}})); err != nil {
+		t.Fatal(err)
+	}
+	conn, reader := dialTCPServer(t, NewLobbyTCPServer(lobby, DefaultGameID))
+	if reply := command(t, conn, reader, "LOOK p1"); reply != "version 0\n1x2\ndown\ndown\n" {
+		t.Errorf("Unexpected LOOK reply %q", reply)
+	}
+
+	lobby.DeleteGame(DefaultGameID)
+	if reply := command(t, conn, reader, "LOOK p1"); reply != "ERROR Unknown game \"default\"\n" {
+		t.Errorf("Expected an error for the deleted game, got %q", reply)
+	}
+
+	if _, err := lobby.CreateGame(DefaultGameID, NewBoard([][]Card{{NewCard("B")}, {NewCard("B")}})); err != nil {
+		t.Fatal(err)
+	}
+	if reply := command(t, conn, reader, "FLIP p1 1 0"); reply != "version 1\n2x1\ndown\nmy B\n" {
+		t.Errorf("Expected the flip to reach the new game, got %q", reply)
+	}
+}
+
+// Test that a reset connection cancels the FLIP waiting for a card (rule 1-D)
+func TestTCPCloseCancelsWaitingFlip(t *testing.T) {
+	board, server := newTestServer(t, "1x3\nA\nB\nA\n", time.Second)
+	get(t, server.URL+"/flip/player2/0,0")
+	conn, _ := dialTCP(t, board)
+
+	conn.Write([]byte("FLIP player1 0 0\n"))
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 1)
+	// O a doua comandă stă în coadă cât timp FLIP-ul așteaptă
+	conn.Write([]byte("LOOK player1\n"))
+	// Fără linger, Close trimite RST: serverul vede o eroare de citire, nu EOF
+	conn.(*net.TCPConn).SetLinger(0)
+	conn.Close()
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 0)
+
+	get(t, server.URL+"/flip/player2/0,1") // 2-E: cartea (0, 0) este eliberată
+	if got := board.FormatBoard("player1"); got != "1x3\nup A\nup B\ndown\n" {
+		t.Errorf("The departed player's flip should not take the card, got %q", got)
+	}
+}
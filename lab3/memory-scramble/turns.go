@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// TurnError descrie un flip refuzat în modul pe ture: nu este rândul jucătorului
+type TurnError struct {
+	Player string // Jucătorul care a încercat flip-ul
+	Active string // Jucătorul care este la rând
+}
+
+// Error implementează interfața error
+func (e *TurnError) Error() string {
+	return fmt.Sprintf("Not your turn: waiting for %s", e.Active)
+}
+
+// SetTurnBased activează sau dezactivează modul pe ture
+//
+// Specification:
+//
+//	Parameters:
+//	  - on: true pentru ca doar jucătorul activ să poată întoarce cărți
+//	Postconditions:
+//	  - Jucătorii intră în ordinea turelor la primul /join/ sau flip (vezi Join)
+//	  - Ordinea și jucătorul activ se păstrează dacă modul e dezactivat și reactivat
+//	  - Dacă jucătorul activ s-a schimbat, version este incrementat și listeners
+//	    sunt notificați
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu)
+func (b *Board) SetTurnBased(on bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	active := b.activePlayer()
+	b.turnBased = on
+	if b.activePlayer() != active {
+		b.turnMoved = true
+		b.commitTurn()
+	}
+}
+
+// Join adaugă jucătorul în joc
+//
+// Specification:
+//
+//	Parameters:
+//	  - playerID: identificatorul jucătorului
+//	Returns:
+//	  - error: *SpectatorError dacă playerID este spectator (vezi Spectate)
+//	Postconditions:
+//	  - Jucătorul are o stare (vezi GetPlayerState)
+//	  - În modul pe ture, jucătorul este adăugat la sfârșitul ordinii turelor dacă
+//	    nu era deja; primul jucător intrat este la rând
+//	  - Dacă jucătorul activ s-a schimbat, version este incrementat și listeners
+//	    sunt notificați
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu, apoi playerStatesMu)
+func (b *Board) Join(playerID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkPlayer(playerID); err != nil {
+		return err
+	}
+	b.GetPlayerState(playerID)
+	b.join(playerID)
+	b.commitTurn()
+	return nil
+}
+
+// ActivePlayer returnează jucătorul care este la rând
+//
+// Specification:
+//
+//	Returns:
+//	  - string: jucătorul activ, sau "" dacă jocul nu e pe ture, nu are încă
+//	    jucători sau s-a terminat
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește mu.RLock)
+func (b *Board) ActivePlayer() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.activePlayer()
+}
+
+// activePlayer este ActivePlayer fără lock
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu
+func (b *Board) activePlayer() string {
+	if !b.turnBased || len(b.turnOrder) == 0 || b.gameOver {
+		return ""
+	}
+	return b.turnOrder[b.turn]
+}
+
+// join adaugă playerID la ordinea turelor, în modul pe ture
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - Dacă jocul e pe ture și playerID nu e în turnOrder, este adăugat la sfârșit
+//	    și intrarea este adăugată în journal; primul jucător devine activ
+func (b *Board) join(playerID string) {
+	if !b.turnBased {
+		return
+	}
+	for _, joined := range b.turnOrder {
+		if joined == playerID {
+			return
+		}
+	}
+	b.turnOrder = append(b.turnOrder, playerID)
+	if len(b.turnOrder) == 1 {
+		b.turn = 0
+		b.turnMoved = true
+		b.turnStarted = b.now()
+	}
+	log.Printf("Player %s joined the turn order as #%d", playerID, len(b.turnOrder))
+	b.logOperation(JournalEntry{Op: OpJoin, Player: playerID})
+}
+
+// commitTurn publică schimbările făcute doar ordinii turelor (ex: de join)
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock) și nu a modificat cărțile
+//	Postconditions:
+//	  - Dacă jucătorul activ s-a schimbat, version este incrementat și listeners
+//	    sunt notificați
+//	  - Operațiile înregistrate cu logOperation sunt scrise în journal
+func (b *Board) commitTurn() {
+	if b.turnMoved {
+		// Schimbarea jucătorului activ apare în look (linia "turn"), deci e vizibilă
+		b.turnMoved = false
+		b.publishVersion()
+	}
+	b.flushJournal()
+}
+
+// checkTurn verifică dacă playerID poate întoarce o carte acum
+//
+// Specification:
+//
+//	Returns:
+//	  - error: *TurnError dacă jocul e pe ture și alt jucător este la rând, altfel nil
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - playerID este adăugat la ordinea turelor dacă nu era (vezi join)
+func (b *Board) checkTurn(playerID string) error {
+	b.join(playerID)
+	if active := b.activePlayer(); active != "" && active != playerID {
+		return &TurnError{Player: playerID, Active: active}
+	}
+	return nil
+}
+
+// endTurn dă rândul următorului jucător, dacă playerID era la rând
+//
+// Specification:
+//
+//	Preconditions:
+//	  - Apelantul deține b.mu (Lock)
+//	Postconditions:
+//	  - În modul pe ture, dacă playerID este jucătorul activ, următorul jucător din
+//	    turnOrder (circular) devine activ; altfel starea nu se modifică
+func (b *Board) endTurn(playerID string) {
+	if b.activePlayer() != playerID || playerID == "" {
+		return
+	}
+	b.turn = (b.turn + 1) % len(b.turnOrder)
+	b.turnMoved = true
+	b.turnStarted = b.now()
+	log.Printf("Turn passes from %s to %s", playerID, b.turnOrder[b.turn])
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test that turns follow the join order, pass after a mismatch and stay after a match
+func TestTurnRotation(t *testing.T) {
+	board := NewBoard([][]Card{
+		{NewCard("A"), NewCard("A"), NewCard("B")},
+		{NewCard("B"), NewCard("C"), NewCard("C")},
+	})
+	board.SetTurnBased(true)
+	board.Join("player1")
+	board.Join("player2")
+	ctx := context.Background()
+
+	var turnErr *TurnError
+	if err := FlipCard(ctx, board, 0, 0, "player2"); !errors.As(err, &turnErr) || turnErr.Active != "player1" {
+		t.Fatalf("Expected player2 to wait for player1, got %v", err)
+	}
+	if got := board.FormatBoard("player2"); got != "2x3\ndown\ndown\ndown\ndown\ndown\ndown\nturn player1\n" {
+		t.Errorf("Rejected flip should not change the board, got %q", got)
+	}
+
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 1, "player1") // 2-D: player1 rămâne la rând
+	if active := board.ActivePlayer(); active != "player1" {
+		t.Errorf("Expected player1 to keep the turn after a match, got %q", active)
+	}
+
+	FlipCard(ctx, board, 0, 2, "player1") // 3-A, apoi 1-B
+	FlipCard(ctx, board, 1, 1, "player1") // 2-E: rândul trece la player2
+	if active := board.ActivePlayer(); active != "player2" {
+		t.Errorf("Expected the turn to pass to player2 after a mismatch, got %q", active)
+	}
+	if err := FlipCard(ctx, board, 1, 0, "player2"); err != nil {
+		t.Errorf("Expected player2 to flip on their turn, got %v", err)
+	}
+
+	// Un jucător nou intră la sfârșitul ordinii
+	FlipCard(ctx, board, 1, 2, "player3")
+	FlipCard(ctx, board, 1, 1, "player2") // 2-E
+	if active := board.ActivePlayer(); active != "player3" {
+		t.Errorf("Expected player3 after player2, got %q", active)
+	}
+}
+
+// Test that a turn-based game answers 409 with a reason and shows the turn in look
+func TestTurnBasedServer(t *testing.T) {
+	board, server := newTestServer(t, "1x4\nA\nB\nA\nB\n", time.Second)
+	board.SetTurnBased(true)
+
+	if _, body := get(t, server.URL+"/join/player1"); body != "1x4\ndown\ndown\ndown\ndown\nturn player1\n" {
+		t.Errorf("Unexpected join response %q", body)
+	}
+	status, body := get(t, server.URL+"/flip/player2/0,0")
+	if status != http.StatusConflict || !strings.Contains(body, "Not your turn: waiting for player1") {
+		t.Errorf("Expected 409 naming player1, got %d %q", status, body)
+	}
+
+	get(t, server.URL+"/flip/player1/0,0")
+	get(t, server.URL+"/flip/player1/0,1")
+	if _, body := get(t, server.URL+"/look/watcher"); body != "1x4\nup A\nup B\ndown\ndown\nturn player2\n" {
+		t.Errorf("Expected player2's turn after the mismatch, got %q", body)
+	}
+	if snapshot := getJSON(t, server.URL+"/look/watcher"); snapshot.Turn != "player2" {
+		t.Errorf("Expected turn player2 in JSON, got %q", snapshot.Turn)
+	}
+}
+
+// Test that the turn order survives snapshots and journal replay
+func TestTurnsPersist(t *testing.T) {
+	var journal bytes.Buffer
+	board := newJournalBoard(&journal)
+	board.SetTurnBased(true)
+	ctx := context.Background()
+	FlipCard(ctx, board, 0, 0, "player1")
+	FlipCard(ctx, board, 0, 1, "player2") // refuzat, dar player2 intră în joc
+	FlipCard(ctx, board, 0, 2, "player1") // 2-E
+
+	var saved bytes.Buffer
+	if err := board.WriteSnapshot(&saved); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadSnapshot(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := newJournalBoard(nil)
+	if _, err := Replay(replayed, strings.NewReader(journal.String()), -1); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, other := range map[string]*Board{"snapshot": restored, "replay": replayed} {
+		if active := other.ActivePlayer(); active != "player2" {
+			t.Errorf("%s: expected player2 to be active, got %q", name, active)
+		}
+		if err := FlipCard(ctx, other, 1, 0, "player1"); err == nil {
+			t.Errorf("%s: expected player1 to wait for their turn", name)
+		}
+	}
+}
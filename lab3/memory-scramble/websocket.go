@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// websocketGUID este sufixul fix din RFC 6455 folosit la calculul Sec-WebSocket-Accept
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketMessage este dimensiunea maximă a unui mesaj primit de la client
+const maxWebSocketMessage = 64 << 10
+
+// Opcode-urile de frame din RFC 6455
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// Codurile de închidere din RFC 6455 folosite de server
+const (
+	wsCloseProtocolError = 1002
+	wsCloseUnsupported   = 1003
+	wsCloseTooBig        = 1009
+)
+
+// errWebSocketClosed este returnată de ReadMessage după ce clientul a închis conexiunea
+var errWebSocketClosed = errors.New("websocket closed by peer")
+
+// wsConn este partea de server a unei conexiuni WebSocket (RFC 6455), doar cu mesaje text
+// Representation Invariants:
+//   - conn și rw sunt conexiunea preluată prin http.Hijacker
+//
+// Thread Safety:
+//   - ReadMessage poate fi apelat dintr-o singură goroutine
+//   - WriteText și writeClose pot fi apelate din mai multe goroutine (writeMu)
+type wsConn struct {
+	conn    net.Conn          // Conexiunea TCP
+	rw      *bufio.ReadWriter // Buffer-ele conexiunii (pot conține deja date de la client)
+	writeMu sync.Mutex        // Serializează frame-urile trimise
+}
+
+// upgradeWebSocket face handshake-ul WebSocket și preia conexiunea HTTP
+//
+// Specification:
+//
+//	Returns:
+//	  - *wsConn: conexiunea WebSocket, după răspunsul 101 Switching Protocols
+//	  - error: non-nil dacă request-ul nu e un handshake valid; în acest caz
+//	    răspunsul de eroare (400, 426 sau 500) a fost deja trimis
+//	Postconditions:
+//	  - Dacă reușește, apelantul trebuie să închidă conexiunea cu Close
+//	  - Orice origin este acceptat, ca la CORS pentru celelalte endpoint-uri
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		writeError(w, http.StatusBadRequest, "Expected a WebSocket upgrade")
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeError(w, http.StatusUpgradeRequired, "Unsupported WebSocket version")
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if nonce, err := base64.StdEncoding.DecodeString(key); err != nil || len(nonce) != 16 {
+		writeError(w, http.StatusBadRequest, "Invalid Sec-WebSocket-Key")
+		return nil, errors.New("invalid websocket key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "WebSocket not supported")
+		return nil, errors.New("response writer cannot be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(accept[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+// headerHasToken verifică dacă un header cu valori separate prin virgulă conține token
+// (fără să țină cont de majuscule), ex: "Connection: keep-alive, Upgrade"
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage citește următorul mesaj text de la client
+//
+// Specification:
+//
+//	Returns:
+//	  - string: mesajul, reasamblat din frame-uri de continuare dacă e cazul
+//	  - error: errWebSocketClosed dacă clientul a închis conexiunea,
+//	    altă eroare dacă conexiunea s-a rupt sau clientul a încălcat protocolul
+//	Postconditions:
+//	  - Ping-urile primite între timp au primit pong
+//	  - La close sau la o eroare de protocol, clientului i s-a trimis un frame close
+func (c *wsConn) ReadMessage() (string, error) {
+	var message []byte
+	started := false
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return "", err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return "", err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			// Răspunde cu același cod, cum cere RFC 6455
+			if len(payload) >= 2 {
+				payload = payload[:2]
+			}
+			c.writeFrame(wsOpClose, payload)
+			return "", errWebSocketClosed
+		case wsOpText:
+			if started {
+				return "", c.fail(wsCloseProtocolError, "expected continuation frame")
+			}
+			started = true
+		case wsOpContinuation:
+			if !started {
+				return "", c.fail(wsCloseProtocolError, "unexpected continuation frame")
+			}
+		case wsOpBinary:
+			return "", c.fail(wsCloseUnsupported, "binary messages are not supported")
+		default:
+			return "", c.fail(wsCloseProtocolError, fmt.Sprintf("unknown opcode %#x", opcode))
+		}
+
+		if len(message)+len(payload) > maxWebSocketMessage {
+			return "", c.fail(wsCloseTooBig, "message too big")
+		}
+		message = append(message, payload...)
+		if fin {
+			return string(message), nil
+		}
+	}
+}
+
+// readFrame citește un frame și îi demaschează payload-ul
+//
+// Specification:
+//
+//	Returns:
+//	  - fin, opcode, payload: câmpurile frame-ului
+//	  - error: non-nil dacă conexiunea s-a rupt sau frame-ul e invalid
+//	    (nemascat, biți RSV setați, frame de control fragmentat sau prea mare)
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.rw, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(wsCloseProtocolError, "reserved bits set")
+	}
+	if !masked {
+		return false, 0, nil, c.fail(wsCloseProtocolError, "client frames must be masked")
+	}
+	isControl := opcode&0x8 != 0
+	if isControl && (!fin || length > 125) {
+		return false, 0, nil, c.fail(wsCloseProtocolError, "invalid control frame")
+	}
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.rw, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.rw, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > maxWebSocketMessage {
+		return false, 0, nil, c.fail(wsCloseTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteText trimite un mesaj text într-un singur frame
+//
+// Specification:
+//
+//	Returns:
+//	  - error: non-nil dacă scrierea pe conexiune a eșuat
+//	Thread Safety:
+//	  - Funcția este thread-safe (folosește writeMu)
+func (c *wsConn) WriteText(text string) error {
+	return c.writeFrame(wsOpText, []byte(text))
+}
+
+// writeFrame trimite un frame nemascat (frame-urile serverului nu se maschează)
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length <= 125:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// writeClose trimite un frame close cu codul și motivul date
+func (c *wsConn) writeClose(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return c.writeFrame(wsOpClose, append(payload, reason...))
+}
+
+// fail trimite un frame close pentru o eroare de protocol și returnează eroarea
+func (c *wsConn) fail(code int, reason string) error {
+	c.writeClose(code, reason)
+	return errors.New("websocket: " + reason)
+}
+
+// Close închide conexiunea TCP
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
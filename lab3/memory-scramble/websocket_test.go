@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWebSocket este un client WebSocket minimal pentru teste
+type testWebSocket struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebSocket face handshake-ul WebSocket cu serverul de test pe path-ul dat
+func dialWebSocket(t *testing.T, serverURL, path string) *testWebSocket {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(serverURL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	io.WriteString(conn, "GET "+path+" HTTP/1.1\r\n"+
+		"Host: localhost\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+	// Exemplul din RFC 6455, secțiunea 1.3
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected Sec-WebSocket-Accept %q", accept)
+	}
+	return &testWebSocket{conn: conn, reader: reader}
+}
+
+// send trimite un mesaj text mascat, cum cere RFC 6455 pentru clienți
+func (ws *testWebSocket) send(t *testing.T, text string) {
+	t.Helper()
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | wsOpText, 0x80 | byte(len(text))}
+	frame = append(frame, mask...)
+	for i := 0; i < len(text); i++ {
+		frame = append(frame, text[i]^mask[i%4])
+	}
+	if _, err := ws.conn.Write(frame); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+// receive citește următorul frame trimis de server
+func (ws *testWebSocket) receive(t *testing.T) (byte, string) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		io.ReadFull(ws.reader, extended[:])
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		io.ReadFull(ws.reader, extended[:])
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return header[0] & 0x0F, string(payload)
+}
+
+// Test that the WebSocket sends the board, answers commands and pushes changes
+func TestWebSocketCommandsAndPushes(t *testing.T) {
+	_, server := newTestServer(t, "1x2\nA\nB\n", time.Second)
+	ws := dialWebSocket(t, server.URL, "/ws/player1")
+	if _, text := ws.receive(t); text != "1x2\ndown\ndown\n" {
+		t.Fatalf("Expected initial board, got %q", text)
+	}
+
+	// player2 întoarce o carte prin HTTP; player1 primește schimbarea fără să ceară
+	get(t, server.URL+"/flip/player2/0,1")
+	if _, text := ws.receive(t); text != "1x2\ndown\nup B\n" {
+		t.Fatalf("Expected pushed board, got %q", text)
+	}
+
+	ws.send(t, "flip 5,5")
+	if _, text := ws.receive(t); text != "error Invalid position 5,5" {
+		t.Errorf("Expected invalid position error, got %q", text)
+	}
+	ws.send(t, "look")
+	if _, text := ws.receive(t); text != "ok look\n1x2\ndown\nup B\n" {
+		t.Errorf("Expected look reply, got %q", text)
+	}
+	ws.send(t, "jump 0,0")
+	if _, text := ws.receive(t); !strings.HasPrefix(text, "error Unknown command") {
+		t.Errorf("Expected unknown command error, got %q", text)
+	}
+}
+
+// Test that a flip over the WebSocket gets the board in reply
+func TestWebSocketFlip(t *testing.T) {
+	_, server := newTestServer(t, "1x2\nA\nB\n", time.Second)
+	ws := dialWebSocket(t, server.URL, "/ws/player1")
+	ws.receive(t)
+
+	ws.send(t, "flip 0,0")
+	// Răspunsul la flip și push-ul schimbării pot veni în orice ordine
+	replies := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		_, text := ws.receive(t)
+		replies[text] = true
+	}
+	if !replies["ok flip 0,0\n1x2\nmy A\ndown\n"] || !replies["1x2\nmy A\ndown\n"] {
+		t.Errorf("Expected a reply and a push after flip, got %v", replies)
+	}
+}
+
+// Test that the server answers a close frame and rejects plain HTTP requests
+func TestWebSocketCloseAndHandshake(t *testing.T) {
+	_, server := newTestServer(t, "1x2\nA\nB\n", time.Second)
+	ws := dialWebSocket(t, server.URL, "/ws/player1")
+	ws.receive(t)
+
+	ws.conn.Write([]byte{0x80 | wsOpClose, 0x80 | 2, 0, 0, 0, 0, 0x03, 0xE8})
+	if opcode, payload := ws.receive(t); opcode != wsOpClose || payload != "\x03\xe8" {
+		t.Errorf("Expected close 1000 in reply, got opcode %#x payload %q", opcode, payload)
+	}
+
+	if status, _ := get(t, server.URL+"/ws/player1"); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 without upgrade, got %d", status)
+	}
+}
+
+// Test that closing the socket while a flip waits (rule 1-D) cancels the flip
+func TestWebSocketCloseCancelsWaitingFlip(t *testing.T) {
+	board, server := newTestServer(t, "1x3\nA\nB\nA\n", time.Second)
+	get(t, server.URL+"/flip/player2/0,0")
+	ws := dialWebSocket(t, server.URL, "/ws/player1")
+	ws.receive(t)
+
+	ws.send(t, "flip 0,0")
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 1)
+	// O a doua comandă stă în coadă cât timp flip-ul așteaptă
+	ws.send(t, "look")
+	ws.conn.Close()
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 0)
+
+	get(t, server.URL+"/flip/player2/0,1") // 2-E: cartea (0, 0) este eliberată
+	if got := board.FormatBoard("player1"); got != "1x3\nup A\nup B\ndown\n" {
+		t.Errorf("The departed player's flip should not take the card, got %q", got)
+	}
+}
+
+// Test that a client overflowing the command queue is closed without running the queued commands
+func TestWebSocketOverflowSkipsQueuedCommands(t *testing.T) {
+	board, server := newTestServer(t, "1x3\nA\nB\nA\n", time.Second)
+	get(t, server.URL+"/flip/player2/0,0")
+	ws := dialWebSocket(t, server.URL, "/ws/player1")
+	ws.receive(t)
+
+	ws.send(t, "flip 0,0")
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 1)
+	for i := 0; i <= maxQueuedCommands; i++ {
+		ws.send(t, "flip 0,2")
+	}
+	waitForWaiters(t, board, Position{Row: 0, Col: 0}, 0)
+	time.Sleep(50 * time.Millisecond)
+
+	board.mu.RLock()
+	defer board.mu.RUnlock()
+	if board.Cards[0][2].FaceUp {
+		t.Error("Commands queued before the overflow should not run")
+	}
+}